@@ -0,0 +1,188 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/config"
+)
+
+const configUsage = `Usage of goio config:
+  goio config init [preset]
+    	write a goio.yaml file to the current directory, defaults to the
+    	default preset
+  goio config validate [file]
+    	validate a goio.yaml file, defaults to the goio.yaml file found in the
+    	current directory or any parent directory
+  goio config migrate [file]
+    	rewrite a goio.yaml file in place using the latest apiVersion, comments
+    	are not preserved
+`
+
+// configCommand runs the goio config sub commands and returns the exit code
+// for the application
+func configCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 1
+	}
+	switch args[0] {
+	case "init":
+		return configInit(args[1:])
+	case "validate":
+		return configValidate(args[1:])
+	case "migrate":
+		return configMigrate(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, configUsage)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "unknown config command %q\n%s", args[0], configUsage)
+	return 1
+}
+
+// configInit writes a preset to the goio.yaml file in the current directory
+func configInit(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 1
+	}
+	name := defaultPreset
+	if len(args) == 1 {
+		name = args[0]
+	}
+	data, ok := preset(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown preset %q, must be one of %s\n", name, strings.Join(presetNames(), ", "))
+		return 1
+	}
+	if _, err := os.Stat("goio.yaml"); err == nil {
+		fmt.Fprint(os.Stderr, "goio.yaml already exists, cowardly refusing to overwrite\n")
+		return 1
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "unable to stat \"goio.yaml\": %s\n", err.Error())
+		return 1
+	}
+	if err := os.WriteFile("goio.yaml", data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write to path \"goio.yaml\", %s\n", err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "wrote the %s preset to goio.yaml\n", name)
+	return 0
+}
+
+// configValidate reports every problem of a configuration file on stderr
+func configValidate(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 1
+	}
+	path, err := configPath(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read configuration file %s: %s\n", path, err.Error())
+		return 1
+	}
+	problems, err := config.Validate(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err.Error())
+		return exitCodeInvalidConfig
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", path, p.String())
+	}
+	if len(problems) != 0 {
+		return exitCodeInvalidConfig
+	}
+	fmt.Fprintf(os.Stdout, "%s is valid\n", path)
+	return 0
+}
+
+// configMigrate rewrites a configuration file using the latest apiVersion
+func configMigrate(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 1
+	}
+	path, err := configPath(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to stat %q: %s\n", path, err.Error())
+		return 1
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read configuration file %s: %s\n", path, err.Error())
+		return 1
+	}
+	version, err := config.Version(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err.Error())
+		return exitCodeInvalidConfig
+	}
+	if version == v1beta1.APIVersion {
+		fmt.Fprintf(os.Stdout, "%s already uses apiVersion %s\n", path, v1beta1.APIVersion)
+		return 0
+	}
+	conf, err := config.LoadV1beta1(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return exitCodeInvalidConfig
+	}
+	out, err := config.Marshal(conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	if err = os.WriteFile(path, out, info.Mode()); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write to path %q, %s\n", path, err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "migrated %s from apiVersion %s to %s\n", path, version, v1beta1.APIVersion)
+	return 0
+}
+
+// configPath returns the configuration file given as the only argument or the
+// goio.yaml file found in the current directory or any parent directory
+func configPath(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("unable to get current working directory: %s", err.Error())
+	}
+	path, found, err := findFile(currentDir, "goio.yaml")
+	if err != nil {
+		return "", fmt.Errorf("error occurred finding configuration file goio.yaml: %v", err)
+	}
+	if !found {
+		return "", fmt.Errorf("error occurred finding configuration file goio.yaml")
+	}
+	return path, nil
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/config"
+	"github.com/go-imports-organizer/goio/pkg/excludes"
+	"github.com/go-imports-organizer/goio/pkg/groups"
+	"github.com/go-imports-organizer/goio/pkg/ignore"
+	"github.com/go-imports-organizer/goio/pkg/imports"
+	"github.com/go-imports-organizer/goio/pkg/module"
+)
+
+// invalidConfigError is returned when a configuration file can not be loaded or
+// holds invalid Regular Expressions
+type invalidConfigError struct {
+	error
+}
+
+// loadConfig loads the configuration file at path, when it extends its parent
+// the goio.yaml file found in the parent directories is loaded and extended,
+// or the built-in default configuration when there is none
+func loadConfig(path string) (v1beta1.Config, error) {
+	conf, err := config.LoadV1beta1(path)
+	if err != nil {
+		return v1beta1.Config{}, err
+	}
+	if conf.Extends != v1beta1.ExtendsParent {
+		return conf, nil
+	}
+	parentPath, found, err := findFile(filepath.Dir(filepath.Dir(path)), "goio.yaml")
+	if err != nil {
+		return v1beta1.Config{}, fmt.Errorf("error occurred finding configuration file goio.yaml extended by %s: %v", path, err)
+	}
+	var parent v1beta1.Config
+	if found {
+		parent, err = loadConfig(parentPath)
+	} else {
+		data, _ := preset(defaultPreset)
+		parent, err = config.Parse("built-in default configuration", data)
+	}
+	if err != nil {
+		return v1beta1.Config{}, err
+	}
+	return config.Extend(parent, conf), nil
+}
+
+// buildExcludes builds the excludes of the configuration file confPath whose
+// path excludes and globs are matched relative to basePath
+func buildExcludes(e []v1beta1.Exclude, confPath string, basePath string) (excludes.Any, error) {
+	matchers, err := excludes.Build(e, basePath)
+	if err != nil {
+		return nil, invalidConfigError{fmt.Errorf("error occurred building excludes from %s: %s", confPath, err.Error())}
+	}
+	return matchers, nil
+}
+
+// settings are the configuration, excludes and Rules that apply to the files
+// of a directory
+type settings struct {
+	// conf is the configuration that applies to the directory
+	conf v1beta1.Config
+	// confPath is the configuration file that conf was loaded from
+	confPath string
+	// mod is the module that the directory belongs to
+	mod module.Module
+	// excludes are the excludes of every configuration file that applies to
+	// the directory
+	excludes excludes.Any
+	// gitIgnores and goioIgnores are the .gitignore and .goioignore files that
+	// apply to the directory, from the outermost to the innermost directory
+	gitIgnores  []*ignore.File
+	goioIgnores []*ignore.File
+	// rules are used to organize the files of the directory
+	rules *imports.Rules
+	// matcher combines the excludes and the ignore files, it is built when it
+	// is first used
+	matcher excludes.Matcher
+}
+
+// newSettings builds the settings for the files of the module from the
+// configuration conf that was loaded from confPath
+func newSettings(conf v1beta1.Config, confPath string, matchers excludes.Any, mod module.Module) (*settings, error) {
+	groupRegExpMatchers, displayOrder, err := groups.Build(conf.Groups, conf.MatchOrder, mod)
+	if err != nil {
+		return nil, invalidConfigError{fmt.Errorf("error occurred building groups from %s: %s", confPath, err.Error())}
+	}
+	return &settings{
+		conf:     conf,
+		confPath: confPath,
+		mod:      mod,
+		excludes: matchers,
+		rules: &imports.Rules{
+			GroupRegExpMatchers:    groupRegExpMatchers,
+			DisplayOrder:           displayOrder,
+			KeepImportDeclarations: conf.Options.KeepImportDeclarations,
+			Generated:              conf.Options.Generated,
+		},
+	}, nil
+}
+
+// excluded returns true if the file or directory at path is excluded by the
+// excludes of the configuration files or ignored by a .goioignore file, or a
+// .gitignore file when the gitignore option is set
+func (s *settings) excluded(path string, isDir bool) bool {
+	if s.matcher == nil {
+		// The .goioignore files take precedence over the .gitignore files so
+		// that they can re-include files that git ignores
+		ignores := s.goioIgnores
+		if s.conf.Options.GitIgnore {
+			ignores = append(append([]*ignore.File{}, s.gitIgnores...), s.goioIgnores...)
+		}
+		s.matcher = excludes.Any{s.excludes, ignore.Matcher(ignores)}
+	}
+	return s.matcher.Match(path, isDir)
+}
+
+// withIgnores returns a copy of the settings that also applies the ignore files
+// of the directory dir, the settings are returned as is when it has none
+func (s *settings) withIgnores(dir string) (*settings, error) {
+	gitIgnore, err := ignore.Load(filepath.Join(dir, ignore.GitIgnore))
+	if err != nil {
+		return nil, err
+	}
+	goioIgnore, err := ignore.Load(filepath.Join(dir, ignore.GoioIgnore))
+	if err != nil {
+		return nil, err
+	}
+	if gitIgnore == nil && goioIgnore == nil {
+		return s, nil
+	}
+	c := *s
+	c.matcher = nil
+	if gitIgnore != nil {
+		c.gitIgnores = append(append([]*ignore.File{}, s.gitIgnores...), gitIgnore)
+	}
+	if goioIgnore != nil {
+		c.goioIgnores = append(append([]*ignore.File{}, s.goioIgnores...), goioIgnore)
+	}
+	return &c, nil
+}
+
+// directories finds the settings that apply to the files of a directory below
+// the root module. A directory that holds a go.mod file is a nested module whose
+// files are organized with its own module name. A directory that holds a
+// goio.yaml file uses that configuration for itself and its subdirectories,
+// either on its own or extending the configuration of its parent directory.
+// Other directories use the settings of their parent directory. The settings
+// are cached per directory.
+type directories struct {
+	rootPath string
+	root     *settings
+	cache    map[string]*settings
+}
+
+// newDirectories returns the directories of the root module at rootPath that
+// uses the root settings. The ignore files of the root module and of its parent
+// directories up to the root of the git repository, including the
+// .git/info/exclude file, apply to the root settings.
+func newDirectories(rootPath string, root *settings) (*directories, error) {
+	rootPath, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine absolute path of %q: %s", rootPath, err.Error())
+	}
+	dirs := []string{rootPath}
+	for dir := rootPath; ; {
+		isRepository, err := fileExists(filepath.Join(dir, ".git"))
+		if err != nil {
+			return nil, err
+		}
+		if isRepository {
+			exclude, err := ignore.Load(filepath.Join(dir, ".git", "info", "exclude"))
+			if err != nil {
+				return nil, err
+			}
+			if exclude != nil {
+				exclude.Dir = dir
+				root.gitIgnores = append(root.gitIgnores, exclude)
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// Outside of a git repository only the root module is used
+			dirs = []string{rootPath}
+			break
+		}
+		dirs = append([]string{parent}, dirs...)
+		dir = parent
+	}
+	for _, dir := range dirs {
+		if root, err = root.withIgnores(dir); err != nil {
+			return nil, err
+		}
+	}
+	return &directories{
+		rootPath: rootPath,
+		root:     root,
+		cache:    map[string]*settings{rootPath: root},
+	}, nil
+}
+
+// settings returns the settings for the files of the directory dir
+func (d *directories) settings(dir string) (*settings, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine absolute path of %q: %s", dir, err.Error())
+	}
+	if s, ok := d.cache[dir]; ok {
+		return s, nil
+	}
+	if rel, err := filepath.Rel(d.rootPath, dir); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		// Directories outside of the root module use the root settings
+		return d.root, nil
+	}
+	parent, err := d.settings(filepath.Dir(dir))
+	if err != nil {
+		return nil, err
+	}
+	s, err := directorySettings(dir, parent)
+	if err != nil {
+		return nil, err
+	}
+	if s, err = s.withIgnores(dir); err != nil {
+		return nil, err
+	}
+	d.cache[dir] = s
+	return s, nil
+}
+
+// directorySettings builds the settings of the directory dir from the settings
+// of its parent directory, the parent settings are returned when the directory
+// holds neither a go.mod nor a goio.yaml file
+func directorySettings(dir string, parent *settings) (*settings, error) {
+	hasGoMod, err := fileExists(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	confPath := filepath.Join(dir, "goio.yaml")
+	hasConf, err := fileExists(confPath)
+	if err != nil {
+		return nil, err
+	}
+	if !hasGoMod && !hasConf {
+		return parent, nil
+	}
+	// The ignore files of the parent directories keep applying
+	inherit := func(s *settings, err error) (*settings, error) {
+		if err != nil {
+			return nil, err
+		}
+		s.gitIgnores, s.goioIgnores = parent.gitIgnores, parent.goioIgnores
+		return s, nil
+	}
+
+	mod := parent.mod
+	if hasGoMod {
+		if mod, err = module.Find(dir); err != nil {
+			return nil, fmt.Errorf("error occurred finding module path of %s: %s", dir, err.Error())
+		}
+	}
+	if !hasConf {
+		return inherit(newSettings(parent.conf, parent.confPath, parent.excludes, mod))
+	}
+
+	conf, err := config.LoadV1beta1(confPath)
+	if err != nil {
+		return nil, invalidConfigError{fmt.Errorf("error occurred loading configuration file: %s", err.Error())}
+	}
+	matchers, err := buildExcludes(conf.Excludes, confPath, dir)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Extends == v1beta1.ExtendsParent {
+		if conf.Merge.Excludes != v1beta1.MergeReplace {
+			matchers = append(append(excludes.Any{}, parent.excludes...), matchers...)
+		}
+		conf = config.Extend(parent.conf, conf)
+	}
+	return inherit(newSettings(conf, confPath, matchers, mod))
+}
+
+// fileExists returns true if the file at path exists
+func fileExists(path string) (bool, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to stat %q: %s", path, err.Error())
+	}
+	return true, nil
+}
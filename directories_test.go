@@ -0,0 +1,182 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/config"
+	"github.com/go-imports-organizer/goio/pkg/module"
+)
+
+func TestDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                 "module example.com/root\n\ngo 1.21\n",
+		"pkg/one/one.go":         "package one\n",
+		"nested/go.mod":          "module example.com/nested\n\ngo 1.21\n",
+		"nested/pkg/two.go":      "package pkg\n",
+		"configured/go.mod":      "module example.com/configured\n\ngo 1.21\n",
+		"configured/goio.yaml":   "apiVersion: goio/v1beta1\nkind: Config\nexcludes:\n  - matchtype: name\n    regexp: ^skip$\ngroups:\n  - name: module\n    regexp:\n      - \"%{module}%\"\n",
+		"invalid/go.mod":         "module example.com/invalid\n\ngo 1.21\n",
+		"invalid/goio.yaml":      "apiVersion: goio/v1beta1\nkind: Config\ngroups:\n  - name: module\n    regexp:\n      - \"[a\"\n",
+		"configured/pkg/skip.go": "package pkg\n",
+		"staging/goio.yaml":      "apiVersion: goio/v1beta1\nkind: Config\nextends: parent\nexcludes:\n  - matchtype: path\n    regexp: ^generated$\ngroups:\n  - name: k8s\n    regexp:\n      - ^k8s\\.io/\nmatchorder:\n  - module\n  - k8s\n",
+		"staging/src/go.mod":     "module k8s.io/staging\n\ngo 1.21\n",
+	})
+	data, _ := preset(defaultPreset)
+	conf, err := config.Parse("default", data)
+	if err != nil {
+		t.Fatalf("config.Parse() error = %v", err)
+	}
+	mod, err := module.Find(root)
+	if err != nil {
+		t.Fatalf("module.Find() error = %v", err)
+	}
+	rootExcludes, err := buildExcludes(conf.Excludes, "default", root)
+	if err != nil {
+		t.Fatalf("buildExcludes() error = %v", err)
+	}
+	rootSettings, err := newSettings(conf, "default", rootExcludes, mod)
+	if err != nil {
+		t.Fatalf("newSettings() error = %v", err)
+	}
+	dirs, err := newDirectories(root, rootSettings)
+	if err != nil {
+		t.Fatalf("newDirectories() error = %v", err)
+	}
+
+	tests := []struct {
+		dir        string
+		wantModule string
+		wantConf   string
+	}{
+		{dir: root, wantModule: "example.com/root", wantConf: "default"},
+		{dir: filepath.Join(root, "pkg", "one"), wantModule: "example.com/root", wantConf: "default"},
+		{dir: filepath.Join(root, "nested", "pkg"), wantModule: "example.com/nested", wantConf: "default"},
+		{dir: filepath.Join(root, "configured", "pkg"), wantModule: "example.com/configured", wantConf: filepath.Join(root, "configured", "goio.yaml")},
+	}
+	for _, tt := range tests {
+		s, err := dirs.settings(tt.dir)
+		if err != nil {
+			t.Fatalf("settings(%s) error = %v", tt.dir, err)
+		}
+		if s.confPath != tt.wantConf {
+			t.Errorf("settings(%s) configuration = %s, want %s", tt.dir, s.confPath, tt.wantConf)
+		}
+		if matched := s.rules.GroupRegExpMatchers[0].RegExp.MatchString(tt.wantModule + "/pkg"); !matched || s.rules.GroupRegExpMatchers[0].Bucket != "module" {
+			t.Errorf("settings(%s) first group %s does not match module %s", tt.dir, s.rules.GroupRegExpMatchers[0].Bucket, tt.wantModule)
+		}
+	}
+
+	staging, err := dirs.settings(filepath.Join(root, "staging", "src"))
+	if err != nil {
+		t.Fatalf("settings() error = %v", err)
+	}
+	if got, want := staging.rules.DisplayOrder, append(append([]string{}, rootSettings.rules.DisplayOrder...), "k8s"); !reflect.DeepEqual(got, want) {
+		t.Errorf("extending settings display order = %v, want %v", got, want)
+	}
+	if got := staging.rules.GroupRegExpMatchers[1].Bucket; got != "k8s" {
+		t.Errorf("extending settings second group = %s, want k8s", got)
+	}
+	if !staging.excluded(filepath.Join(root, "staging", "src", "vendor"), true) || !staging.excluded(filepath.Join(root, "staging", "generated"), true) {
+		t.Errorf("extending settings do not apply the excludes of both configuration files")
+	}
+	if staging.excluded(filepath.Join(root, "staging", "src", "generated"), true) {
+		t.Errorf("extending settings match path excludes relative to the wrong directory")
+	}
+
+	s, err := dirs.settings(filepath.Join(root, "configured", "pkg"))
+	if err != nil {
+		t.Fatalf("settings() error = %v", err)
+	}
+	if !s.excluded(filepath.Join(root, "configured", "pkg", "skip"), false) || rootSettings.excluded(filepath.Join(root, "pkg", "skip"), false) {
+		t.Errorf("excludes of the nested goio.yaml are not applied to the nested module only")
+	}
+
+	if _, err := dirs.settings(filepath.Join(root, "invalid")); !errors.As(err, &invalidConfigError{}) {
+		t.Errorf("settings() error = %v, want an invalid configuration error", err)
+	}
+}
+
+func TestDirectoriesIgnoreFiles(t *testing.T) {
+	repository := t.TempDir()
+	root := filepath.Join(repository, "module")
+	writeFiles(t, repository, map[string]string{
+		".git/info/exclude":      "local.go\n",
+		".gitignore":             "*.pb.go\n",
+		"module/go.mod":          "module example.com/root\n\ngo 1.21\n",
+		"module/.goioignore":     "/tools/\n",
+		"module/pkg/.gitignore":  "!keep.pb.go\n",
+		"module/pkg/.goioignore": "!types.pb.go\n",
+	})
+	tests := []struct {
+		name      string
+		gitIgnore bool
+		path      string
+		isDir     bool
+		want      bool
+	}{
+		{name: "goioignore", path: "tools", isDir: true, want: true},
+		{name: "goioignore is anchored", path: "pkg/tools", isDir: true, want: false},
+		{name: "gitignore is disabled", path: "api.pb.go", want: false},
+		{name: "gitignore of the repository", gitIgnore: true, path: "api.pb.go", want: true},
+		{name: "git info exclude", gitIgnore: true, path: "pkg/local.go", want: true},
+		{name: "nested gitignore negation", gitIgnore: true, path: "pkg/keep.pb.go", want: false},
+		{name: "goioignore takes precedence", gitIgnore: true, path: "pkg/types.pb.go", want: false},
+		{name: "nested gitignore", gitIgnore: true, path: "pkg/api.pb.go", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := v1beta1.Config{Options: v1beta1.Options{GitIgnore: tt.gitIgnore}}
+			rootSettings, err := newSettings(conf, "goio.yaml", nil, module.Module{Name: "example.com/root"})
+			if err != nil {
+				t.Fatalf("newSettings() error = %v", err)
+			}
+			dirs, err := newDirectories(root, rootSettings)
+			if err != nil {
+				t.Fatalf("newDirectories() error = %v", err)
+			}
+			path := filepath.Join(root, filepath.FromSlash(tt.path))
+			s, err := dirs.settings(filepath.Dir(path))
+			if err != nil {
+				t.Fatalf("settings() error = %v", err)
+			}
+			if got := s.excluded(path, tt.isDir); got != tt.want {
+				t.Errorf("excluded(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// writeFiles writes the files to the directory dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create directory for %s: %s", name, err.Error())
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write %s: %s", name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gitSelection selects the files to organize from the local git repository
+// instead of walking the module
+type gitSelection struct {
+	// changedSince selects the files that changed since the revision, in the
+	// working tree or the index
+	changedSince string
+	// staged selects the files that are staged in the index
+	staged bool
+	// untracked selects the files that are not tracked and not ignored by git
+	untracked bool
+}
+
+// enabled returns true if any file is selected from the git repository
+func (g gitSelection) enabled() bool {
+	return len(g.changedSince) != 0 || g.staged || g.untracked
+}
+
+// files returns the Go files below the directory dir that are selected, relative
+// to dir and sorted. Deleted files are not selected.
+func (g gitSelection) files(dir string) ([]string, error) {
+	commands := [][]string{}
+	if len(g.changedSince) != 0 {
+		commands = append(commands, []string{"diff", "--name-only", "--relative", "--diff-filter=d", "-z", g.changedSince, "--"})
+	}
+	if g.staged {
+		commands = append(commands, []string{"diff", "--cached", "--name-only", "--relative", "--diff-filter=d", "-z", "--"})
+	}
+	if g.untracked {
+		commands = append(commands, []string{"ls-files", "--others", "--exclude-standard", "-z", "--"})
+	}
+
+	selected := map[string]bool{}
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list files with git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()+" "+err.Error()))
+		}
+		for _, name := range strings.Split(string(out), "\x00") {
+			if strings.HasSuffix(name, ".go") {
+				selected[filepath.FromSlash(name)] = true
+			}
+		}
+	}
+
+	paths := []string{}
+	for path := range selected {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// inPaths returns true if the path is one of the paths or below one of them, all
+// paths are selected when there are none
+func inPaths(path string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, p := range paths {
+		absP, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		if absPath == absP || strings.HasPrefix(absPath, absP+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitFileExcluded returns true if the file at path, relative to the current
+// directory, or one of the directories that hold it is excluded, the same way
+// that walking the module skips excluded files and directories
+func gitFileExcluded(dirs *directories, path string) bool {
+	if settingsFor(dirs, filepath.Dir(path)).excluded(path, false) {
+		return true
+	}
+	for dir := filepath.Dir(path); dir != "." && dir != string(os.PathSeparator); dir = filepath.Dir(dir) {
+		if settingsFor(dirs, filepath.Dir(dir)).excluded(dir, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitSelection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=goio", "-c", "user.email=goio@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v error = %v: %s", args, err, out)
+		}
+	}
+	writeFiles(t, root, map[string]string{
+		"go.mod":           "module example.com/root\n\ngo 1.21\n",
+		"unchanged.go":     "package root\n",
+		"modified.go":      "package root\n",
+		"deleted.go":       "package root\n",
+		"pkg/committed.go": "package pkg\n",
+	})
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "base")
+	writeFiles(t, root, map[string]string{
+		"pkg/committed.go": "package pkg\n\nimport \"fmt\"\n",
+		".gitignore":       "ignored.go\n",
+	})
+	git("commit", "-q", "-a", "-m", "change")
+	writeFiles(t, root, map[string]string{
+		"modified.go":     "package root\n\nimport \"os\"\n",
+		"staged.go":       "package root\n",
+		"untracked.go":    "package root\n",
+		"ignored.go":      "package root\n",
+		"untracked.txt":   "not a Go file\n",
+		"pkg/new/new.go":  "package new\n",
+		"pkg/notes.md":    "notes\n",
+		"pkg/new/new2.go": "package new\n",
+	})
+	if err := os.Remove(filepath.Join(root, "deleted.go")); err != nil {
+		t.Fatalf("unable to remove deleted.go: %s", err.Error())
+	}
+	git("add", "staged.go")
+
+	tests := []struct {
+		name      string
+		selection gitSelection
+		dir       string
+		want      []string
+	}{
+		{
+			name:      "nothing selected",
+			selection: gitSelection{},
+			dir:       root,
+			want:      []string{},
+		},
+		{
+			name:      "changed since revision",
+			selection: gitSelection{changedSince: "base"},
+			dir:       root,
+			want:      []string{"modified.go", filepath.Join("pkg", "committed.go"), "staged.go"},
+		},
+		{
+			name:      "staged",
+			selection: gitSelection{staged: true},
+			dir:       root,
+			want:      []string{"staged.go"},
+		},
+		{
+			name:      "untracked",
+			selection: gitSelection{untracked: true},
+			dir:       root,
+			want:      []string{filepath.Join("pkg", "new", "new.go"), filepath.Join("pkg", "new", "new2.go"), "untracked.go"},
+		},
+		{
+			name:      "union relative to a subdirectory",
+			selection: gitSelection{changedSince: "HEAD", untracked: true},
+			dir:       filepath.Join(root, "pkg"),
+			want:      []string{filepath.Join("new", "new.go"), filepath.Join("new", "new2.go")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selection.files(tt.dir)
+			if err != nil {
+				t.Fatalf("files() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (gitSelection{}).enabled() {
+		t.Errorf("enabled() = true, want false when nothing is selected")
+	}
+	if _, err := (gitSelection{changedSince: "does-not-exist"}).files(root); err == nil {
+		t.Errorf("files() error = nil, want an error for an unknown revision")
+	}
+}
+
+func TestInPaths(t *testing.T) {
+	tests := []struct {
+		path  string
+		paths []string
+		want  bool
+	}{
+		{path: "a/b.go", paths: nil, want: true},
+		{path: "a/b.go", paths: []string{"a"}, want: true},
+		{path: "a/b.go", paths: []string{"a/b.go"}, want: true},
+		{path: "ab/c.go", paths: []string{"a"}, want: false},
+		{path: "c.go", paths: []string{"a", "b"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := inPaths(filepath.FromSlash(tt.path), tt.paths); got != tt.want {
+			t.Errorf("inPaths(%q, %v) = %v, want %v", tt.path, tt.paths, got, tt.want)
+		}
+	}
+}
@@ -16,8 +16,11 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -26,26 +29,49 @@ import (
 	"sync"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
 	"github.com/go-imports-organizer/goio/pkg/config"
-	"github.com/go-imports-organizer/goio/pkg/excludes"
-	"github.com/go-imports-organizer/goio/pkg/groups"
+	"github.com/go-imports-organizer/goio/pkg/constraints"
+	"github.com/go-imports-organizer/goio/pkg/diff"
 	"github.com/go-imports-organizer/goio/pkg/imports"
 	"github.com/go-imports-organizer/goio/pkg/module"
+	"github.com/go-imports-organizer/goio/pkg/report"
 	"github.com/go-imports-organizer/goio/pkg/version"
 )
 
+// exitCodeInvalidConfig is used when the configuration file can not be loaded
+// or holds invalid Regular Expressions, it is distinct from the exit code 1 that
+// is used when files need to be organized
+const exitCodeInvalidConfig = 2
+
 var (
 	wg          sync.WaitGroup
-	files       = make(chan string)
-	resultsChan = make(chan string)
+	files       = make(chan imports.File)
+	resultsChan = make(chan imports.Result)
 	hasResults  = false
 	pathList    v1alpha1.PathListFlags
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(configCommand(os.Args[2:]))
+	}
+
 	listOnly := flag.Bool("l", false, "only list files that need to be organized (no changes made)")
+	diffOnly := flag.Bool("d", false, "display diffs of the changes instead of organizing files (no changes made)")
 	flag.Var(&pathList, "p", "specify individual paths to organize, use multiple times for multiple paths. defaults to entire module directory")
 	versionOnly := flag.Bool("v", false, "print version and exit")
+	workers := flag.Int("j", runtime.GOMAXPROCS(0), "number of files to organize in parallel")
+	stdin := flag.Bool("stdin", false, "organize the source read from stdin and write it to stdout")
+	filename := flag.String("filename", "", "path of the source read from stdin, used to find the module and goio.yaml. defaults to the current directory")
+	outputFormat := flag.String("format", report.FormatText, "output format of the results, one of text, json or sarif")
+	tags := flag.String("tags", "", "comma-separated list of build tags, files whose build constraints are not satisfied are skipped")
+	goos := flag.String("goos", "", "operating system for the build constraints, files for other operating systems are skipped. defaults to the one of the go command")
+	goarch := flag.String("goarch", "", "architecture for the build constraints, files for other architectures are skipped. defaults to the one of the go command")
+	var selection gitSelection
+	flag.StringVar(&selection.changedSince, "changed-since", "", "only organize the Go files that changed since the git revision")
+	flag.BoolVar(&selection.staged, "staged", false, "only organize the Go files that are staged in git")
+	flag.BoolVar(&selection.untracked, "untracked", false, "only organize the Go files that are not tracked and not ignored by git")
 	flag.Parse()
 
 	// set CPUPROFILE=<filename> to create a <filename>.pprof cpu profile file
@@ -65,79 +91,161 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *workers < 1 {
+		fmt.Fprintf(os.Stderr, "invalid number of workers %d, must be at least 1\n", *workers)
+		os.Exit(1)
+	}
+
+	reporter, err := report.New(*outputFormat, os.Stdout, os.Stderr, *diffOnly)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// Files are only filtered by their build constraints when a build tag,
+	// operating system or architecture is requested
+	var buildConstraints *constraints.Context
+	if len(*tags) != 0 || len(*goos) != 0 || len(*goarch) != 0 {
+		buildConstraints = constraints.New(*goos, *goarch, strings.FieldsFunc(*tags, func(r rune) bool { return r == ',' || r == ' ' }))
+	}
+
 	currentDir, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "unable to get current working directory: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	// Find the Go module name and path
-	goModuleName, goModulePath, err := module.FindGoModuleNameAndPath(currentDir)
+	// When reading from stdin the module and configuration are found relative
+	// to the supplied filename instead of the current directory
+	lookupDir := currentDir
+	if *stdin && len(*filename) != 0 {
+		absFilename, err := filepath.Abs(*filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to determine absolute path of %q: %s\n", *filename, err.Error())
+			os.Exit(1)
+		}
+		lookupDir = filepath.Dir(absFilename)
+	}
+
+	// Find the Go module name, path and version
+	mod, err := module.Find(lookupDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error occurred finding module path: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	// Find a goio.yaml file in the current directory or any parent directory
-	path, found, err := findFile(currentDir, "goio.yaml")
+	// Find a goio.yaml file in the module directory or any parent directory,
+	// the goio.yaml files of subdirectories are applied while walking
+	path, found, err := findFile(mod.Path, "goio.yaml")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error occurred finding configuration file goio.yaml: %v\n", err)
 		os.Exit(1)
 	}
-	if !found {
-		fmt.Fprint(os.Stderr, "error occurred finding configuration file goio.yaml\n")
-		os.Exit(1)
-	}
 
-	// Load the configuration from the goio.yaml file
-	conf, err := config.Load(path)
+	// Load the configuration from the goio.yaml file, or use the built-in
+	// default configuration when there is none
+	var conf v1beta1.Config
+	if found {
+		conf, err = loadConfig(path)
+	} else {
+		if !*stdin {
+			fmt.Fprint(os.Stderr, "no configuration file goio.yaml found, using the built-in default configuration\n")
+		}
+		path = "built-in default configuration"
+		data, _ := preset(defaultPreset)
+		conf, err = config.Parse(path, data)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error occurred loading configuration file: %s\n", err.Error())
-		os.Exit(1)
+		os.Exit(exitCodeInvalidConfig)
+	}
+
+	// Build the Regular Expressions for excluding files/folders and the Rules
+	// for organizing the files of the module
+	rootExcludes, err := buildExcludes(conf.Excludes, path, mod.Path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(exitCodeInvalidConfig)
+	}
+	rootSettings, err := newSettings(conf, path, rootExcludes, mod)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(exitCodeInvalidConfig)
 	}
 
-	// Build the Regular Expressions for excluding files/folders
-	excludeByNameRegExp, excludeByPathRegExp := excludes.Build(conf.Excludes)
+	// Nested modules, goio.yaml files and ignore files in subdirectories are
+	// applied to the files below them
+	dirs, err := newDirectories(mod.Path, rootSettings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
 
-	// Build the Regular Expressions and DisplayOrder for the group definitions
-	groupRegExpMatchers, displayOrder := groups.Build(conf.Groups, goModuleName)
+	if *stdin {
+		os.Exit(organizeStdin(*filename, settingsFor(dirs, lookupDir).rules, buildConstraints, *listOnly, *diffOnly, reporter))
+	}
 
-	// Read results from the resultsChan and write them to stdout
+	// Read results from the resultsChan and write them to stdout in the order
+	// that the files were queued, regardless of which worker finished first
+	resultsDone := make(chan struct{})
 	go func() {
-		for {
-			r := <-resultsChan
-			if len(r) != 0 {
-				fmt.Fprintf(os.Stdout, "%s\n", r)
+		defer close(resultsDone)
+		imports.OrderResults(&resultsChan, func(r imports.Result) {
+			if r.Status == imports.StatusChanged {
+				hasResults = true
 			}
-		}
+			if err := reporter.Report(r); err != nil {
+				fmt.Fprintf(os.Stderr, "unable to report result for %q: %s\n", r.Path, err.Error())
+			}
+		})
 	}()
 
-	// Add one (1) to the WaitGroup so that we can know when the Formatting in completed
-	wg.Add(1)
+	// Add the number of workers to the WaitGroup so that we can know when the Formatting in completed
+	wg.Add(*workers)
 
-	// Start up the Format worker so that it is ready when we start queuing up files
-	go imports.Format(&files, &resultsChan, &hasResults, &wg, groupRegExpMatchers, displayOrder, listOnly)
-
-	// Set the basePath for use later
-	basePath := goModulePath + "/"
+	// Start up the Format workers so that they are ready when we start queuing up files
+	for i := 0; i < *workers; i++ {
+		go imports.Format(&files, &resultsChan, &wg, rootSettings.rules.GroupRegExpMatchers, rootSettings.rules.DisplayOrder, rootSettings.rules.KeepImportDeclarations, listOnly, diffOnly)
+	}
 
-	// Change our working directory to the goModulePath
-	err = os.Chdir(goModulePath)
+	// Change our working directory to the module path
+	err = os.Chdir(mod.Path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to change directory to %q: %s\n", goModulePath, err.Error())
+		fmt.Fprintf(os.Stderr, "unable to change directory to %q: %s\n", mod.Path, err.Error())
 		os.Exit(1)
 	}
 
-	// Pre-optization so that we can skip the Name or Path matches if they are empty
-	excludeByNameRegExpLenOk := len(excludeByNameRegExp.String()) != 0
-	excludeByPathRegExpLenOk := len(excludeByPathRegExp.String()) != 0
+	// queued is the number of files that have been queued, it is used to
+	// index the files so that the results can be reported in order
+	queued := 0
+
+	// When files are selected from git only those that are below the paths
+	// supplied via the -p flag and are not excluded are queued
+	var gitFiles []string
+	if selection.enabled() {
+		if gitFiles, err = selection.files(mod.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+	for _, path := range gitFiles {
+		if !inPaths(path, pathList) || gitFileExcluded(dirs, path) {
+			continue
+		}
+		files <- imports.File{Index: queued, Path: path, Rules: settingsFor(dirs, filepath.Dir(path)).rules, Constraints: buildConstraints}
+		queued++
+	}
 
-	// If no paths are supplied via the -p flag use the current directory
-	if len(pathList) == 0 {
-		pathList = append(pathList, goModulePath)
+	// If no paths are supplied via the -p flag use the current directory, the
+	// paths are not walked when files are selected from git
+	walkPaths := pathList
+	if selection.enabled() {
+		walkPaths = nil
+	} else if len(walkPaths) == 0 {
+		walkPaths = append(walkPaths, mod.Path)
 	}
 
-	for _, path := range pathList {
+	for _, path := range walkPaths {
 		f, err := os.Stat(path)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "unable to stat %q: %s\n", path, err.Error())
@@ -146,12 +254,14 @@ func main() {
 
 		// If the path is a Go file
 		if strings.HasSuffix(path, ".go") {
+			s := settingsFor(dirs, filepath.Dir(path))
 			// If the files name or path matches an exclude Regular Expression, skip it
-			if (excludeByNameRegExpLenOk && excludeByNameRegExp.MatchString(f.Name())) || (excludeByPathRegExpLenOk && excludeByPathRegExp.MatchString(path)) {
+			if s.excluded(path, false) {
 				continue
 			}
 			// If the file is not excluded by name or path, queue it for organizing
-			files <- path
+			files <- imports.File{Index: queued, Path: path, Rules: s.rules, Constraints: buildConstraints}
+			queued++
 
 		} else if f.IsDir() {
 			// If the path is a directory
@@ -162,11 +272,13 @@ func main() {
 				name := f.Name()
 				isDir := f.IsDir()
 				isGoFile := strings.HasSuffix(name, ".go")
-				relativePath := strings.Replace(path, basePath, "", 1)
 				// If the object is not a directory and not a Go file, skip it
 				if isDir || isGoFile {
+					// Excludes are matched using the settings of the directory
+					// that holds the object
+					s := settingsFor(dirs, filepath.Dir(path))
 					// If the objects name or path matches an exclude Regular Expression, skip it
-					if (excludeByNameRegExpLenOk && excludeByNameRegExp.MatchString(name)) || (excludeByPathRegExpLenOk && excludeByPathRegExp.MatchString(relativePath)) {
+					if s.excluded(path, isDir) {
 						// If the object is a Directory, skip the entire thing
 						if isDir {
 							return filepath.SkipDir
@@ -176,7 +288,8 @@ func main() {
 
 					// If the object is a Go file and is not excluded, queue it for organizing
 					if isGoFile {
-						files <- relativePath
+						files <- imports.File{Index: queued, Path: strings.Replace(path, mod.Path+"/", "", 1), Rules: s.rules, Constraints: buildConstraints}
+						queued++
 					}
 				}
 				return nil
@@ -193,8 +306,14 @@ func main() {
 	// Wait for all files to be processed
 	wg.Wait()
 
-	// Close the resultsChan as all formatting should be completed
+	// Close the resultsChan as all formatting should be completed and wait
+	// for the remaining results to be written
 	close(resultsChan)
+	<-resultsDone
+	if err := reporter.Done(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to complete report: %s\n", err.Error())
+		os.Exit(1)
+	}
 
 	// set MEMPROFILE=<filename> to create a <filename>.pprof memory profile file
 	if len(os.Getenv("MEMPROFILE")) != 0 {
@@ -211,9 +330,85 @@ func main() {
 			os.Exit(1)
 		}
 	}
-	if *listOnly && hasResults {
+	if (*listOnly || *diffOnly) && hasResults {
+		os.Exit(1)
+	}
+}
+
+// organizeStdin organizes the source read from stdin and writes the result to
+// stdout, or reports its result when listOnly or diffOnly are set. The source
+// is skipped when it is excluded by the build constraints c. It returns the
+// exit code for the application.
+func organizeStdin(filename string, rules *imports.Rules, c *constraints.Context, listOnly bool, diffOnly bool, reporter report.Reporter) int {
+	name := filename
+	if len(name) == 0 {
+		name = "<standard input>"
+	}
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read from stdin: %s\n", err.Error())
+		return 1
+	}
+	// Skipped files are written back unchanged
+	status, reason := imports.SkipConstraints(name, src, c)
+	if len(status) == 0 {
+		status, reason = imports.Skip(name, src, rules.Generated)
+	}
+	out := src
+	if len(status) == 0 {
+		if out, err = imports.Organize(name, src, rules.GroupRegExpMatchers, rules.DisplayOrder, rules.KeepImportDeclarations); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return 1
+		}
+	}
+	if !listOnly && !diffOnly {
+		os.Stdout.Write(out)
+		return 0
+	}
+	result := imports.Result{Path: name, Status: imports.StatusUnchanged}
+	if len(status) != 0 {
+		result.Status = status
+		result.Reason = reason
+	} else if !bytes.Equal(src, out) {
+		result.Status = imports.StatusChanged
+		if result.Moved, err = imports.MovedImports(name, src, out); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return 1
+		}
+		if result.Blocks, err = imports.Blocks(name, src, out); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return 1
+		}
+		if diffOnly {
+			result.Diff = diff.Unified(name, src, out)
+		}
+	}
+	if err := reporter.Report(result); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to report result for %q: %s\n", name, err.Error())
+		return 1
+	}
+	if err := reporter.Done(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to complete report: %s\n", err.Error())
+		return 1
+	}
+	if result.Status == imports.StatusChanged {
+		return 1
+	}
+	return 0
+}
+
+// settingsFor returns the settings for the files of the directory dir and exits
+// when the settings of a nested module or goio.yaml file can not be built
+func settingsFor(dirs *directories, dir string) *settings {
+	s, err := dirs.settings(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		if errors.As(err, &invalidConfigError{}) {
+			os.Exit(exitCodeInvalidConfig)
+		}
 		os.Exit(1)
 	}
+	return s
 }
 
 func findFile(path, fileName string) (string, bool, error) {
@@ -20,10 +20,15 @@ import (
 	"strings"
 )
 
+// APIVersion is the apiVersion of v1alpha1 configuration files, files without
+// an apiVersion are v1alpha1 files as well
+const APIVersion string = "goio/v1alpha1"
+
 // RegExpMatcher
 type RegExpMatcher struct {
 	Bucket string         `yaml:"bucket"`
 	RegExp *regexp.Regexp `yaml:"regexp"`
+	Sort   Sort           `yaml:"sort"`
 }
 
 const (
@@ -41,6 +46,32 @@ type Exclude struct {
 	RegExp string `yaml:"regexp"`
 }
 
+const (
+	// SortOrderLexical sorts imports by their path, this is the default
+	SortOrderLexical string = "lexical"
+	// SortOrderSegments sorts imports by the number of segments in their path
+	// and then by their path
+	SortOrderSegments string = "segments"
+	// SortOrderCaseInsensitive sorts imports by their path ignoring case
+	SortOrderCaseInsensitive string = "caseinsensitive"
+	// SortNamedFirst places named imports before all other imports of the group
+	SortNamedFirst string = "first"
+	// SortNamedLast places named imports after all other imports of the group
+	SortNamedLast string = "last"
+)
+
+// Sort defines how the imports within a Group are sorted
+type Sort struct {
+	// Order is the order that the imports are sorted in, defaults to lexical
+	Order string `yaml:"order"`
+	// Named moves the named imports to the first or last position of the group,
+	// by default named imports are sorted along with all other imports
+	Named string `yaml:"named"`
+	// SeparateBlankAndDot moves blank (_) and dot (.) imports into their own
+	// block at the end of the group
+	SeparateBlankAndDot bool `yaml:"separateblankanddot"`
+}
+
 // Group defines a block of imports
 type Group struct {
 	// MatchOrder is the order is which the Regular Expression will be matched
@@ -50,14 +81,23 @@ type Group struct {
 	Description string `yaml:"description"`
 	// RegExp is the Regular Expression that is used to match against the imports Path.Value
 	RegExp []string `yaml:"regexp"`
+	// Sort defines how the imports within the group are sorted
+	Sort Sort `yaml:"sort"`
 }
 
 // Config is the configuration for the Go Imports Organizer
 type Config struct {
+	// APIVersion is the version of the configuration format
+	APIVersion string `yaml:"apiVersion,omitempty"`
+	// Kind is the kind of the configuration, always Config
+	Kind string `yaml:"kind,omitempty"`
 	// Excludes is a slice of Exclude objects
 	Excludes []Exclude `yaml:"excludes"`
 	// Groups is a slice of Group objects
 	Groups []Group `yaml:"groups"`
+	// KeepImportDeclarations organizes each import declaration in a file on its
+	// own instead of merging them into a single declaration
+	KeepImportDeclarations bool `yaml:"keepimportdeclarations"`
 }
 
 // PathListFlags is a type that can store Path objects that are supplied via the -p flag
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"sort"
+
+	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	"github.com/go-imports-organizer/goio/pkg/sorter"
+)
+
+// ConvertFromV1alpha1 converts a v1alpha1 configuration into a v1beta1 one. The
+// description of a v1alpha1 group becomes the name of the v1beta1 group and the
+// numeric matchorder of the groups becomes the MatchOrder list of names.
+func ConvertFromV1alpha1(in v1alpha1.Config) Config {
+	out := Config{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Options:    Options{KeepImportDeclarations: in.KeepImportDeclarations},
+	}
+	for _, e := range in.Excludes {
+		out.Excludes = append(out.Excludes, Exclude{MatchType: e.MatchType, RegExp: e.RegExp})
+	}
+	for _, g := range in.Groups {
+		out.Groups = append(out.Groups, Group{
+			Name:    g.Description,
+			RegExp:  append([]string(nil), g.RegExp...),
+			Options: GroupOptions{Sort: Sort(g.Sort)},
+		})
+	}
+
+	// Work on a copy so that the groups of the input are not reordered
+	byMatchOrder := append([]v1alpha1.Group(nil), in.Groups...)
+	sort.Stable(sorter.SortGroupsByMatchOrder(byMatchOrder))
+	for _, g := range byMatchOrder {
+		out.MatchOrder = append(out.MatchOrder, g.Description)
+	}
+	return out
+}
+
+// ConvertToV1alpha1 converts a v1beta1 configuration into a v1alpha1 one. The
+// name of a v1beta1 group becomes the description of the v1alpha1 group and its
+// position in the MatchOrder becomes its numeric matchorder, the groups that are
+// not listed are matched afterwards. Glob excludes, group descriptions, extends,
+// merge and the options other than keepimportdeclarations have no v1alpha1
+// equivalent and are dropped.
+func ConvertToV1alpha1(in Config) v1alpha1.Config {
+	out := v1alpha1.Config{
+		APIVersion:             v1alpha1.APIVersion,
+		Kind:                   Kind,
+		KeepImportDeclarations: in.Options.KeepImportDeclarations,
+	}
+	for _, e := range in.Excludes {
+		if e.MatchType == ExcludeMatchTypeGlob {
+			continue
+		}
+		out.Excludes = append(out.Excludes, v1alpha1.Exclude{MatchType: e.MatchType, RegExp: e.RegExp})
+	}
+
+	matchOrder := map[string]int{}
+	for i, name := range in.MatchOrder {
+		if _, ok := matchOrder[name]; !ok {
+			matchOrder[name] = i
+		}
+	}
+	next := len(in.MatchOrder)
+	for _, g := range in.Groups {
+		order, ok := matchOrder[g.Name]
+		if !ok {
+			order = next
+			next++
+		}
+		out.Groups = append(out.Groups, v1alpha1.Group{
+			MatchOrder:  order,
+			Description: g.Name,
+			RegExp:      append([]string(nil), g.RegExp...),
+			Sort:        v1alpha1.Sort(g.Options.Sort),
+		})
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+const (
+	// APIVersion is the apiVersion of v1beta1 configuration files
+	APIVersion string = "goio/v1beta1"
+	// Kind is the kind of configuration files
+	Kind string = "Config"
+)
+
+const (
+	// ExcludeMatchTypeName tells an Exclude to match against the file or folder name
+	ExcludeMatchTypeName string = "name"
+	// ExcludeMatchTypeRelativePath tells an Exclude to match against the file or folder path
+	ExcludeMatchTypeRelativePath string = "path"
+	// ExcludeMatchTypeGlob tells an Exclude to match its Glob against the file
+	// or folder path
+	ExcludeMatchTypeGlob string = "glob"
+)
+
+// Exclude defines a file or folder that should be excluded from being organized
+type Exclude struct {
+	// MatchType defines whether the file name or file path should be matched against
+	MatchType string `yaml:"matchtype"`
+	// RegExp is the Regular Expression that is used to match against, it is
+	// used by the name and path match types
+	RegExp string `yaml:"regexp,omitempty"`
+	// Glob is the glob that is used to match against, it is used by the glob
+	// match type
+	Glob string `yaml:"glob,omitempty"`
+}
+
+const (
+	// SortOrderLexical sorts imports by their path, this is the default
+	SortOrderLexical string = "lexical"
+	// SortOrderSegments sorts imports by the number of segments in their path
+	// and then by their path
+	SortOrderSegments string = "segments"
+	// SortOrderCaseInsensitive sorts imports by their path ignoring case
+	SortOrderCaseInsensitive string = "caseinsensitive"
+	// SortNamedFirst places named imports before all other imports of the group
+	SortNamedFirst string = "first"
+	// SortNamedLast places named imports after all other imports of the group
+	SortNamedLast string = "last"
+)
+
+// Sort defines how the imports within a Group are sorted
+type Sort struct {
+	// Order is the order that the imports are sorted in, defaults to lexical
+	Order string `yaml:"order,omitempty"`
+	// Named moves the named imports to the first or last position of the group,
+	// by default named imports are sorted along with all other imports
+	Named string `yaml:"named,omitempty"`
+	// SeparateBlankAndDot moves blank (_) and dot (.) imports into their own
+	// block at the end of the group
+	SeparateBlankAndDot bool `yaml:"separateblankanddot,omitempty"`
+}
+
+// GroupOptions are the options that only apply to a single Group
+type GroupOptions struct {
+	// Sort defines how the imports within the group are sorted
+	Sort Sort `yaml:"sort,omitempty"`
+}
+
+// Group defines a block of imports, the groups are displayed in the order that
+// they are defined in
+type Group struct {
+	// Name identifies the group, it is used to reference the group from the
+	// MatchOrder
+	Name string `yaml:"name"`
+	// Description is a friendly description of the group
+	Description string `yaml:"description,omitempty"`
+	// RegExp is the Regular Expression that is used to match against the imports Path.Value
+	RegExp []string `yaml:"regexp"`
+	// Options are the options of the group
+	Options GroupOptions `yaml:"options,omitempty"`
+}
+
+const (
+	// GeneratedInclude organizes generated files like all other files
+	GeneratedInclude string = "include"
+	// GeneratedSkip skips generated files, this is the default
+	GeneratedSkip string = "skip"
+	// GeneratedOnly only organizes generated files
+	GeneratedOnly string = "only"
+)
+
+// Options are the options that apply to all files
+type Options struct {
+	// KeepImportDeclarations organizes each import declaration in a file on its
+	// own instead of merging them into a single declaration
+	KeepImportDeclarations bool `yaml:"keepimportdeclarations,omitempty"`
+	// GitIgnore excludes the files and folders that are ignored by the
+	// .gitignore files of the repository
+	GitIgnore bool `yaml:"gitignore,omitempty"`
+	// Generated is one of include, skip or only and decides whether files with
+	// a "// Code generated ... DO NOT EDIT." comment are organized, defaults to
+	// skip
+	Generated string `yaml:"generated,omitempty"`
+}
+
+const (
+	// ExtendsParent tells a Config to extend the configuration that applies to
+	// its parent directory
+	ExtendsParent string = "parent"
+	// MergeAppend appends the excludes of a Config to the ones it extends, this
+	// is the default for excludes
+	MergeAppend string = "append"
+	// MergeMerge merges the groups or options of a Config into the ones it
+	// extends, this is the default for groups and options
+	MergeMerge string = "merge"
+	// MergeReplace replaces the excludes, groups or options that a Config extends
+	MergeReplace string = "replace"
+)
+
+// Merge defines how an extending Config is combined with the configuration
+// that it extends
+type Merge struct {
+	// Excludes is either append or replace, defaults to append
+	Excludes string `yaml:"excludes,omitempty"`
+	// Groups is either merge or replace, defaults to merge. Merged groups
+	// replace the groups with the same name and are appended otherwise.
+	Groups string `yaml:"groups,omitempty"`
+	// Options is either merge or replace, defaults to merge. Merged options are
+	// enabled when they are enabled in either configuration.
+	Options string `yaml:"options,omitempty"`
+}
+
+// Config is the configuration for the Go Imports Organizer
+type Config struct {
+	// APIVersion is the version of the configuration format
+	APIVersion string `yaml:"apiVersion"`
+	// Kind is the kind of the configuration, always Config
+	Kind string `yaml:"kind"`
+	// Extends is set to parent to extend the configuration that applies to the
+	// parent directory instead of replacing it
+	Extends string `yaml:"extends,omitempty"`
+	// Merge defines how the configuration is combined with the one it extends
+	Merge Merge `yaml:"merge,omitempty"`
+	// Excludes is a slice of Exclude objects
+	Excludes []Exclude `yaml:"excludes,omitempty"`
+	// Groups is a slice of Group objects in the order that they are displayed
+	Groups []Group `yaml:"groups"`
+	// MatchOrder is the names of the groups in the order that their Regular
+	// Expressions are matched against an import to determine its group. Groups
+	// that are not listed are matched afterwards in the order they are defined.
+	MatchOrder []string `yaml:"matchorder,omitempty"`
+	// Options are the options that apply to all groups
+	Options Options `yaml:"options,omitempty"`
+}
@@ -1,26 +1,106 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
 )
 
-// Load loads the configuration from a yaml file
+// Load loads the configuration from a yaml file in the v1alpha1 shape, v1beta1
+// configuration files are converted with v1beta1.ConvertToV1alpha1. Use
+// LoadV1beta1 to keep the settings that v1alpha1 has no equivalent for.
 func Load(file string) (v1alpha1.Config, error) {
-	var configFile []byte
-	var err error
-	if configFile, err = os.ReadFile(file); err != nil {
+	configFile, err := os.ReadFile(file)
+	if err != nil {
 		return v1alpha1.Config{}, fmt.Errorf("unable to read configuration file %s: %s", file, err.Error())
 	}
+	conf, err := Parse(file, configFile)
+	if err != nil {
+		return v1alpha1.Config{}, err
+	}
+	if version, _ := Version(configFile); version == v1beta1.APIVersion {
+		return v1beta1.ConvertToV1alpha1(conf), nil
+	}
 
 	var config v1alpha1.Config
 	if err = yaml.Unmarshal(configFile, &config); err != nil {
 		return v1alpha1.Config{}, fmt.Errorf("unable to unmarshal file %s: %s", file, err.Error())
 	}
-
 	return config, nil
 }
+
+// LoadV1beta1 loads the configuration from a yaml file, v1alpha1 configuration
+// files are converted to v1beta1
+func LoadV1beta1(file string) (v1beta1.Config, error) {
+	configFile, err := os.ReadFile(file)
+	if err != nil {
+		return v1beta1.Config{}, fmt.Errorf("unable to read configuration file %s: %s", file, err.Error())
+	}
+	return Parse(file, configFile)
+}
+
+// Parse parses the configuration from the contents of a yaml file, the file
+// name is only used in error messages. v1alpha1 configurations are converted
+// to v1beta1. Problems that are warnings do not keep the configuration from
+// loading.
+func Parse(file string, configFile []byte) (v1beta1.Config, error) {
+	problems, err := Validate(configFile)
+	if err != nil {
+		return v1beta1.Config{}, fmt.Errorf("unable to unmarshal file %s: %s", file, err.Error())
+	}
+	if errs := Errors(problems); len(errs) != 0 {
+		return v1beta1.Config{}, &ValidationError{File: file, Problems: errs}
+	}
+
+	version, err := Version(configFile)
+	if err != nil {
+		return v1beta1.Config{}, fmt.Errorf("unable to unmarshal file %s: %s", file, err.Error())
+	}
+	if version == v1beta1.APIVersion {
+		var config v1beta1.Config
+		if err = yaml.Unmarshal(configFile, &config); err != nil {
+			return v1beta1.Config{}, fmt.Errorf("unable to unmarshal file %s: %s", file, err.Error())
+		}
+		return config, nil
+	}
+
+	var config v1alpha1.Config
+	if err = yaml.Unmarshal(configFile, &config); err != nil {
+		return v1beta1.Config{}, fmt.Errorf("unable to unmarshal file %s: %s", file, err.Error())
+	}
+	return v1beta1.ConvertFromV1alpha1(config), nil
+}
+
+// Marshal returns the yaml representation of the configuration
+func Marshal(config v1beta1.Config) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return nil, fmt.Errorf("unable to marshal configuration: %s", err.Error())
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("unable to marshal configuration: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
@@ -1,29 +1,26 @@
 package config
 
 import (
+	"os"
 	"reflect"
 	"strings"
 	"testing"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
 )
 
 func TestLoad(t *testing.T) {
-	type args struct {
-		file string
-	}
 	tests := []struct {
 		name       string
-		args       args
+		file       string
 		want       v1alpha1.Config
 		wantErr    bool
 		wantErrMsg string
 	}{
 		{
 			name: "working config file",
-			args: args{
-				file: "../../test/testdata/config/works.yaml",
-			},
+			file: "../../test/testdata/config/works.yaml",
 			want: v1alpha1.Config{
 				Excludes: []v1alpha1.Exclude{
 					{
@@ -53,15 +50,172 @@ func TestLoad(t *testing.T) {
 					},
 				},
 			},
+		},
+		{
+			name: "v1beta1 config file is converted",
+			file: "../../test/testdata/config/works-v1beta1.yaml",
+			want: v1alpha1.Config{
+				APIVersion: v1alpha1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Excludes: []v1alpha1.Exclude{
+					{
+						MatchType: "name",
+						RegExp:    "^\\.git$",
+					},
+				},
+				Groups: []v1alpha1.Group{
+					{
+						MatchOrder:  1,
+						Description: "standard",
+						RegExp:      []string{"^[a-zA-Z0-9\\/]+$"},
+						Sort:        v1alpha1.Sort{Named: v1alpha1.SortNamedLast},
+					},
+					{
+						MatchOrder:  2,
+						Description: "other",
+						RegExp:      []string{"[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/"},
+					},
+					{
+						MatchOrder:  0,
+						Description: "module",
+						RegExp:      []string{"%{module}%"},
+					},
+				},
+				KeepImportDeclarations: true,
+			},
+		},
+		{
+			name:       "invalid yaml syntax",
+			file:       "../../test/testdata/config/syntax.yaml",
+			wantErr:    true,
+			wantErrMsg: "unable to unmarshal file",
+		},
+		{
+			name:       "unable to read config file",
+			file:       "../../test/testdata/config/notexist.yaml",
+			wantErr:    true,
+			wantErrMsg: "unable to read configuration file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.wantErrMsg) {
+				t.Errorf("Load() gotErrMsg = %v, wantErrMsg = %v", err.Error(), tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadV1beta1(t *testing.T) {
+	type args struct {
+		file string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       v1beta1.Config
+		wantErr    bool
+		wantErrMsg string
+	}{
+		{
+			name: "working config file",
+			args: args{
+				file: "../../test/testdata/config/works.yaml",
+			},
+			want: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Excludes: []v1beta1.Exclude{
+					{
+						MatchType: "name",
+						RegExp:    "^\\.git$",
+					},
+					{
+						MatchType: "name",
+						RegExp:    "^vendor$",
+					},
+				},
+				Groups: []v1beta1.Group{
+					{
+						Name:   "module",
+						RegExp: []string{"%{module}%"},
+					},
+					{
+						Name:   "standard",
+						RegExp: []string{"^[a-zA-Z0-9\\/]+$"},
+					},
+					{
+						Name:   "other",
+						RegExp: []string{"[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/"},
+					},
+				},
+				MatchOrder: []string{"module", "standard", "other"},
+			},
 			wantErr:    false,
 			wantErrMsg: "",
 		},
+		{
+			name: "working v1beta1 config file",
+			args: args{
+				file: "../../test/testdata/config/works-v1beta1.yaml",
+			},
+			want: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Excludes: []v1beta1.Exclude{
+					{
+						MatchType: "name",
+						RegExp:    "^\\.git$",
+					},
+					{
+						MatchType: "glob",
+						Glob:      "**/testdata",
+					},
+				},
+				Groups: []v1beta1.Group{
+					{
+						Name:   "standard",
+						RegExp: []string{"^[a-zA-Z0-9\\/]+$"},
+						Options: v1beta1.GroupOptions{
+							Sort: v1beta1.Sort{Named: v1beta1.SortNamedLast},
+						},
+					},
+					{
+						Name:        "other",
+						Description: "third party modules",
+						RegExp:      []string{"[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/"},
+					},
+					{
+						Name:   "module",
+						RegExp: []string{"%{module}%"},
+					},
+				},
+				MatchOrder: []string{"module", "standard", "other"},
+				Options:    v1beta1.Options{KeepImportDeclarations: true},
+			},
+		},
 		{
 			name: "malformed yaml file",
 			args: args{
 				file: "../../test/testdata/config/malformed.yaml",
 			},
-			want:       v1alpha1.Config{},
+			want:       v1beta1.Config{},
+			wantErr:    true,
+			wantErrMsg: `malformed.yaml:9:5: group is missing the matchorder field`,
+		},
+		{
+			name: "invalid yaml syntax",
+			args: args{
+				file: "../../test/testdata/config/syntax.yaml",
+			},
+			want:       v1beta1.Config{},
 			wantErr:    true,
 			wantErrMsg: "unable to unmarshal file",
 		},
@@ -70,16 +224,16 @@ func TestLoad(t *testing.T) {
 			args: args{
 				file: "../../test/testdata/config/notexist.yaml",
 			},
-			want:       v1alpha1.Config{},
+			want:       v1beta1.Config{},
 			wantErr:    true,
 			wantErrMsg: "unable to read configuration file",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Load(tt.args.file)
+			got, err := LoadV1beta1(tt.args.file)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("LoadV1beta1() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if tt.wantErr {
@@ -88,8 +242,127 @@ func TestLoad(t *testing.T) {
 				}
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Load() = %#v, want %#v", got, tt.want)
+				t.Errorf("LoadV1beta1() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	data := []byte("apiVersion: goio/v1beta1\nkind: Config\ngroups:\n  - name: standard\n    colour: blue\n    regexp:\n      - ^[a-z]+$\nmatchorder:\n  - standard\n  - standard\n")
+	got, err := Parse("goio.yaml", data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got.Groups) != 1 || got.Groups[0].Name != "standard" {
+		t.Errorf("Parse() = %#v, want the standard group", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []Problem
+	}{
+		{
+			name: "valid v1alpha1 file",
+			file: "../../test/testdata/config/works.yaml",
+		},
+		{
+			name: "valid v1beta1 file",
+			file: "../../test/testdata/config/works-v1beta1.yaml",
+		},
+		{
+			name: "valid extending v1beta1 file",
+			file: "../../test/testdata/config/extends-v1beta1.yaml",
+		},
+		{
+			name: "invalid v1beta1 excludes",
+			file: "../../test/testdata/config/invalid-excludes-v1beta1.yaml",
+			want: []Problem{
+				{Line: 4, Column: 5, Message: "exclude is missing the glob field"},
+				{Line: 5, Column: 13, Message: "regexp can not be used with matchtype glob, use glob instead"},
+				{Line: 7, Column: 11, Message: "invalid glob \"[z-a]\": error parsing regexp: invalid character class range: `z-a`"},
+				{Line: 9, Column: 11, Message: "glob can only be used with matchtype glob"},
+				{Line: 16, Column: 14, Message: `unknown generated "sometimes", must be one of include, skip, only`},
+			},
+		},
+		{
+			name: "invalid extending v1beta1 file",
+			file: "../../test/testdata/config/invalid-extends-v1beta1.yaml",
+			want: []Problem{
+				{Line: 3, Column: 10, Message: `unknown extends "grandparent", must be one of parent`},
+				{Line: 6, Column: 12, Message: `unknown merge strategy "inherit", must be one of merge, replace`},
+				{Line: 12, Column: 5, Message: `matchorder references unknown group "module"`},
+			},
+		},
+		{
+			name: "invalid v1alpha1 file",
+			file: "../../test/testdata/config/invalid.yaml",
+			want: []Problem{
+				{Line: 2, Column: 16, Message: `unknown matchtype "folder", must be one of name, path`},
+				{Line: 5, Column: 13, Message: "invalid regexp \"[a-z\": error parsing regexp: missing closing ]: `[a-z`"},
+				{Line: 11, Column: 18, Message: `duplicate description "module", already used on line 7`},
+				{Line: 12, Column: 17, Message: "matchorder must be an integer"},
+				{Line: 16, Column: 14, Message: `unknown sort order "random", must be one of lexical, segments, caseinsensitive`},
+				{Line: 17, Column: 5, Message: "group is missing the matchorder field"},
+				{Line: 20, Column: 5, Message: `unknown field "colour" in group, must be one of description, matchorder, regexp, sort`, Warning: true},
+			},
+		},
+		{
+			name: "invalid v1beta1 file",
+			file: "../../test/testdata/config/invalid-v1beta1.yaml",
+			want: []Problem{
+				{Line: 2, Column: 7, Message: `unknown kind "Configuration", must be one of Config`},
+				{Line: 5, Column: 5, Message: `unknown field "matchorder" in group, must be one of name, description, regexp, options`, Warning: true},
+				{Line: 10, Column: 16, Message: `unknown sort named "middle", must be one of first, last`},
+				{Line: 11, Column: 11, Message: `duplicate name "standard", already used on line 4`},
+				{Line: 12, Column: 13, Message: "regexp must hold at least one Regular Expression"},
+				{Line: 14, Column: 5, Message: `matchorder references unknown group "module"`},
+				{Line: 16, Column: 5, Message: `duplicate group "standard" in matchorder, already used on line 15`, Warning: true},
+				{Line: 18, Column: 27, Message: "keepimportdeclarations must be a boolean"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("unable to read configuration file: %s", err.Error())
+			}
+			got, err := Validate(data)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if len(got) != 0 || len(tt.want) != 0 {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Validate() = %v, want %v", got, tt.want)
+				}
 			}
 		})
 	}
+
+	t.Run("duplicate matchorder is a warning", func(t *testing.T) {
+		got, err := Validate([]byte("groups:\n  - description: a\n    matchorder: 0\n    regexp: [a]\n  - description: b\n    matchorder: 0\n    regexp: [b]\n"))
+		want := []Problem{{Line: 6, Column: 17, Message: "duplicate matchorder 0, already used on line 3", Warning: true}}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Validate() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("unknown apiVersion", func(t *testing.T) {
+		got, err := Validate([]byte("apiVersion: goio/v2\n"))
+		want := []Problem{{Line: 1, Column: 13, Message: "unknown apiVersion \"goio/v2\", must be one of goio/v1alpha1, goio/v1beta1"}}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Validate() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("invalid yaml syntax", func(t *testing.T) {
+		got, err := Validate([]byte("groups: [\n"))
+		if err == nil {
+			t.Errorf("Validate() = %v, want a syntax error", got)
+		}
+	})
 }
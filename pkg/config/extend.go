@@ -0,0 +1,66 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+)
+
+// Extend returns the configuration that results from the child extending the
+// parent, the child overrides the parent groups of the same name, the matchorder
+// and the generated option when it sets them.
+// The excludes and the other groups of the child are appended to the ones of the
+// parent, and a boolean option is enabled when either configuration enables it.
+// A merge of replace for the excludes, groups or options of the child uses those
+// of the child alone and drops the ones of the parent.
+func Extend(parent v1beta1.Config, child v1beta1.Config) v1beta1.Config {
+	result := child
+	result.Extends = ""
+	result.Merge = v1beta1.Merge{}
+
+	if child.Merge.Excludes != v1beta1.MergeReplace {
+		result.Excludes = append(append([]v1beta1.Exclude{}, parent.Excludes...), child.Excludes...)
+	}
+
+	if child.Merge.Groups != v1beta1.MergeReplace {
+		result.Groups = append([]v1beta1.Group{}, parent.Groups...)
+		for _, group := range child.Groups {
+			replaced := false
+			for i := range result.Groups {
+				if result.Groups[i].Name == group.Name {
+					result.Groups[i] = group
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				result.Groups = append(result.Groups, group)
+			}
+		}
+		if len(child.MatchOrder) == 0 {
+			result.MatchOrder = parent.MatchOrder
+		}
+	}
+
+	if child.Merge.Options != v1beta1.MergeReplace {
+		result.Options.KeepImportDeclarations = parent.Options.KeepImportDeclarations || child.Options.KeepImportDeclarations
+		result.Options.GitIgnore = parent.Options.GitIgnore || child.Options.GitIgnore
+		if len(child.Options.Generated) == 0 {
+			result.Options.Generated = parent.Options.Generated
+		}
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+)
+
+func TestExtend(t *testing.T) {
+	parent := v1beta1.Config{
+		APIVersion: v1beta1.APIVersion,
+		Kind:       v1beta1.Kind,
+		Excludes:   []v1beta1.Exclude{{MatchType: v1beta1.ExcludeMatchTypeName, RegExp: "^vendor$"}},
+		Groups: []v1beta1.Group{
+			{Name: "standard", RegExp: []string{`^[a-zA-Z0-9\/]+$`}},
+			{Name: "other", RegExp: []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`}},
+			{Name: "module", RegExp: []string{"%{module}%"}},
+		},
+		MatchOrder: []string{"module", "standard", "other"},
+		Options:    v1beta1.Options{KeepImportDeclarations: true, Generated: v1beta1.GeneratedInclude},
+	}
+	k8s := v1beta1.Group{Name: "k8s", RegExp: []string{`^k8s\.io/`}}
+	other := v1beta1.Group{Name: "other", RegExp: []string{`^github\.com/`}}
+	exclude := v1beta1.Exclude{MatchType: v1beta1.ExcludeMatchTypeRelativePath, RegExp: "^generated$"}
+	tests := []struct {
+		name  string
+		child v1beta1.Config
+		want  v1beta1.Config
+	}{
+		{
+			name: "merge",
+			child: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Extends:    v1beta1.ExtendsParent,
+				Excludes:   []v1beta1.Exclude{exclude},
+				Groups:     []v1beta1.Group{other, k8s},
+			},
+			want: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Excludes:   []v1beta1.Exclude{parent.Excludes[0], exclude},
+				Groups:     []v1beta1.Group{parent.Groups[0], other, parent.Groups[2], k8s},
+				MatchOrder: parent.MatchOrder,
+				Options:    v1beta1.Options{KeepImportDeclarations: true, Generated: v1beta1.GeneratedInclude},
+			},
+		},
+		{
+			name: "replace",
+			child: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Extends:    v1beta1.ExtendsParent,
+				Merge:      v1beta1.Merge{Excludes: v1beta1.MergeReplace, Groups: v1beta1.MergeReplace, Options: v1beta1.MergeReplace},
+				Excludes:   []v1beta1.Exclude{exclude},
+				Groups:     []v1beta1.Group{k8s},
+			},
+			want: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Excludes:   []v1beta1.Exclude{exclude},
+				Groups:     []v1beta1.Group{k8s},
+			},
+		},
+		{
+			name: "match order of the child",
+			child: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Extends:    v1beta1.ExtendsParent,
+				Groups:     []v1beta1.Group{k8s},
+				MatchOrder: []string{"module", "k8s"},
+				Options:    v1beta1.Options{Generated: v1beta1.GeneratedOnly},
+			},
+			want: v1beta1.Config{
+				APIVersion: v1beta1.APIVersion,
+				Kind:       v1beta1.Kind,
+				Excludes:   parent.Excludes,
+				Groups:     append(append([]v1beta1.Group{}, parent.Groups...), k8s),
+				MatchOrder: []string{"module", "k8s"},
+				Options:    v1beta1.Options{KeepImportDeclarations: true, Generated: v1beta1.GeneratedOnly},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extend(parent, tt.child); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extend() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if parent.Groups[1].Name != "other" || len(parent.Groups) != 3 {
+		t.Errorf("Extend() modified the parent configuration")
+	}
+}
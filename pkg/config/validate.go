@@ -0,0 +1,515 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/excludes"
+)
+
+// Problem is a single issue found in a configuration file
+type Problem struct {
+	// Line and Column are the position of the offending YAML node
+	Line   int
+	Column int
+	// Message describes the issue
+	Message string
+	// Warning is set for issues that configuration files were accepted with
+	// before they were validated, such as unknown fields, they only fail goio
+	// config validate
+	Warning bool
+}
+
+func (p Problem) String() string {
+	if p.Warning {
+		return fmt.Sprintf("%d:%d: warning: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+// Errors returns the Problems that are not warnings
+func Errors(problems []Problem) []Problem {
+	errs := []Problem{}
+	for _, p := range problems {
+		if !p.Warning {
+			errs = append(errs, p)
+		}
+	}
+	return errs
+}
+
+// ValidationError holds every Problem found in a configuration file
+type ValidationError struct {
+	// File is the path to the configuration file
+	File     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		lines = append(lines, fmt.Sprintf("%s:%s", e.File, p.String()))
+	}
+	return fmt.Sprintf("invalid configuration file %s:\n%s", e.File, strings.Join(lines, "\n"))
+}
+
+// macroPlaceholders stand in for the macros when a group's Regular Expressions
+// are compiled during validation
+var macroPlaceholders = strings.NewReplacer(
+	`%{module}%`, `^example\.com\/module`,
+	`%{stdlib}%`, `^(?:fmt|os)$`,
+	`%{direct}%`, `^(?:example\.com/direct)(?:/|$)`,
+	`%{indirect}%`, `^(?:example\.com/indirect)(?:/|$)`,
+	`%{replaced}%`, `^(?:example\.com/replaced)(?:/|$)`,
+	`%{workspace}%`, `^(?:example\.com/workspace)(?:/|$)`,
+)
+
+// validator collects the Problems of a configuration file
+type validator struct {
+	problems []Problem
+}
+
+// Validate checks the YAML document of a configuration file against the
+// schema of its apiVersion and returns every Problem that it finds, ordered by
+// their position. An error is only returned when the document is not valid
+// YAML.
+func Validate(data []byte) ([]Problem, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	v := &validator{}
+	if len(doc.Content) == 0 {
+		return v.problems, nil
+	}
+	root := doc.Content[0]
+	version, versionNode := apiVersion(root)
+	switch version {
+	case "", v1alpha1.APIVersion:
+		v.v1alpha1Config(root)
+	case v1beta1.APIVersion:
+		v.v1beta1Config(root)
+	default:
+		v.report(versionNode, "unknown apiVersion %q, must be one of %s, %s", version, v1alpha1.APIVersion, v1beta1.APIVersion)
+	}
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Line != v.problems[j].Line {
+			return v.problems[i].Line < v.problems[j].Line
+		}
+		return v.problems[i].Column < v.problems[j].Column
+	})
+	return v.problems, nil
+}
+
+// Version returns the apiVersion of a configuration file, files without an
+// apiVersion are v1alpha1 files
+func Version(data []byte) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	if len(doc.Content) == 0 {
+		return v1alpha1.APIVersion, nil
+	}
+	version, _ := apiVersion(doc.Content[0])
+	if len(version) == 0 {
+		return v1alpha1.APIVersion, nil
+	}
+	return version, nil
+}
+
+// apiVersion returns the value of the apiVersion field of the root node
+func apiVersion(root *yaml.Node) (string, *yaml.Node) {
+	if root.Kind != yaml.MappingNode {
+		return "", root
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "apiVersion" {
+			return root.Content[i+1].Value, root.Content[i+1]
+		}
+	}
+	return "", root
+}
+
+func (v *validator) report(n *yaml.Node, format string, a ...interface{}) {
+	v.problems = append(v.problems, Problem{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, a...)})
+}
+
+// warn reports a Problem that does not keep the configuration from loading
+func (v *validator) warn(n *yaml.Node, format string, a ...interface{}) {
+	v.problems = append(v.problems, Problem{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, a...), Warning: true})
+}
+
+// mapping checks that the node is a mapping with only the known keys and
+// returns the value nodes by their key
+func (v *validator) mapping(n *yaml.Node, what string, known ...string) map[string]*yaml.Node {
+	values := map[string]*yaml.Node{}
+	if n.Kind != yaml.MappingNode {
+		v.report(n, "%s must be a mapping", what)
+		return values
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		isKnown := false
+		for _, k := range known {
+			if key.Value == k {
+				isKnown = true
+				break
+			}
+		}
+		switch {
+		case !isKnown:
+			v.warn(key, "unknown field %q in %s, must be one of %s", key.Value, what, strings.Join(known, ", "))
+		case values[key.Value] != nil:
+			v.report(key, "duplicate field %q in %s", key.Value, what)
+		default:
+			values[key.Value] = value
+		}
+	}
+	return values
+}
+
+// sequence checks that the node is a sequence and returns its items
+func (v *validator) sequence(n *yaml.Node, what string) []*yaml.Node {
+	if n.Kind != yaml.SequenceNode {
+		v.report(n, "%s must be a list", what)
+		return nil
+	}
+	return n.Content
+}
+
+// scalar decodes a scalar node into out and reports whether that succeeded
+func (v *validator) scalar(n *yaml.Node, what string, kind string, out interface{}) bool {
+	if n.Kind != yaml.ScalarNode || n.Decode(out) != nil {
+		v.report(n, "%s must be %s", what, kind)
+		return false
+	}
+	return true
+}
+
+// oneOf checks that a string scalar holds one of the allowed values
+func (v *validator) oneOf(n *yaml.Node, what string, allowed ...string) {
+	var value string
+	if !v.scalar(n, what, "a string", &value) {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.report(n, "unknown %s %q, must be one of %s", what, value, strings.Join(allowed, ", "))
+}
+
+// regExp checks that a string scalar holds a valid Regular Expression
+func (v *validator) regExp(n *yaml.Node, what string) {
+	var value string
+	if !v.scalar(n, what, "a string", &value) {
+		return
+	}
+	if len(value) == 0 {
+		v.report(n, "%s must not be empty", what)
+		return
+	}
+	if _, err := regexp.Compile(macroPlaceholders.Replace(value)); err != nil {
+		v.report(n, "invalid %s %q: %s", what, value, err.Error())
+	}
+}
+
+// kind checks that the kind of a configuration file is Config
+func (v *validator) kind(n *yaml.Node) {
+	v.oneOf(n, "kind", v1beta1.Kind)
+}
+
+func (v *validator) v1alpha1Config(n *yaml.Node) {
+	fields := v.mapping(n, "configuration", "apiVersion", "kind", "excludes", "groups", "keepimportdeclarations")
+	if kind, ok := fields["kind"]; ok {
+		v.kind(kind)
+	}
+	if excludes, ok := fields["excludes"]; ok {
+		for _, e := range v.sequence(excludes, "excludes") {
+			v.v1alpha1Exclude(e)
+		}
+	}
+	if groups, ok := fields["groups"]; ok {
+		v.v1alpha1Groups(groups)
+	}
+	if keep, ok := fields["keepimportdeclarations"]; ok {
+		var b bool
+		v.scalar(keep, "keepimportdeclarations", "a boolean", &b)
+	}
+}
+
+func (v *validator) v1beta1Config(n *yaml.Node) {
+	fields := v.mapping(n, "configuration", "apiVersion", "kind", "extends", "merge", "excludes", "groups", "matchorder", "options")
+	if kind, ok := fields["kind"]; ok {
+		v.kind(kind)
+	} else if n.Kind == yaml.MappingNode {
+		v.report(n, "configuration is missing the kind field")
+	}
+	// The groups of the parent configuration can only be referenced when they
+	// are merged with the groups of an extending configuration
+	inherited := false
+	if extends, ok := fields["extends"]; ok {
+		v.oneOf(extends, "extends", v1beta1.ExtendsParent)
+		inherited = true
+	}
+	if merge, ok := fields["merge"]; ok {
+		mergeFields := v.mapping(merge, "merge", "excludes", "groups", "options")
+		if _, ok := fields["extends"]; !ok {
+			v.report(merge, "merge requires extends")
+		}
+		if excludes, ok := mergeFields["excludes"]; ok {
+			v.oneOf(excludes, "merge strategy", v1beta1.MergeAppend, v1beta1.MergeReplace)
+		}
+		if groups, ok := mergeFields["groups"]; ok {
+			v.oneOf(groups, "merge strategy", v1beta1.MergeMerge, v1beta1.MergeReplace)
+			if groups.Value == v1beta1.MergeReplace {
+				inherited = false
+			}
+		}
+		if options, ok := mergeFields["options"]; ok {
+			v.oneOf(options, "merge strategy", v1beta1.MergeMerge, v1beta1.MergeReplace)
+		}
+	}
+	if excludes, ok := fields["excludes"]; ok {
+		for _, e := range v.sequence(excludes, "excludes") {
+			v.v1beta1Exclude(e)
+		}
+	}
+	names := map[string]*yaml.Node{}
+	if groups, ok := fields["groups"]; ok {
+		names = v.v1beta1Groups(groups)
+	}
+	if matchOrder, ok := fields["matchorder"]; ok {
+		referenced := map[string]*yaml.Node{}
+		for _, item := range v.sequence(matchOrder, "matchorder") {
+			var name string
+			if !v.scalar(item, "matchorder item", "a group name", &name) {
+				continue
+			}
+			if _, ok := names[name]; !ok && !inherited {
+				v.report(item, "matchorder references unknown group %q", name)
+			} else if first, ok := referenced[name]; ok {
+				v.warn(item, "duplicate group %q in matchorder, already used on line %d", name, first.Line)
+			} else {
+				referenced[name] = item
+			}
+		}
+	}
+	if options, ok := fields["options"]; ok {
+		optionFields := v.mapping(options, "options", "keepimportdeclarations", "gitignore", "generated")
+		if generated, ok := optionFields["generated"]; ok {
+			v.oneOf(generated, "generated", v1beta1.GeneratedInclude, v1beta1.GeneratedSkip, v1beta1.GeneratedOnly)
+		}
+		for _, option := range []string{"keepimportdeclarations", "gitignore"} {
+			if value, ok := optionFields[option]; ok {
+				var b bool
+				v.scalar(value, option, "a boolean", &b)
+			}
+		}
+	}
+}
+
+func (v *validator) v1alpha1Exclude(n *yaml.Node) {
+	fields := v.mapping(n, "exclude", "matchtype", "regexp")
+	if matchType, ok := fields["matchtype"]; ok {
+		v.oneOf(matchType, "matchtype", v1alpha1.ExcludeMatchTypeName, v1alpha1.ExcludeMatchTypeRelativePath)
+	} else if n.Kind == yaml.MappingNode {
+		v.report(n, "exclude is missing the matchtype field")
+	}
+	if r, ok := fields["regexp"]; ok {
+		v.regExp(r, "regexp")
+	} else if n.Kind == yaml.MappingNode {
+		v.report(n, "exclude is missing the regexp field")
+	}
+}
+
+// v1beta1Exclude validates an exclude, the glob match type uses the glob field
+// instead of the regexp field
+func (v *validator) v1beta1Exclude(n *yaml.Node) {
+	fields := v.mapping(n, "exclude", "matchtype", "regexp", "glob")
+	matchType, ok := fields["matchtype"]
+	if ok {
+		v.oneOf(matchType, "matchtype", v1beta1.ExcludeMatchTypeName, v1beta1.ExcludeMatchTypeRelativePath, v1beta1.ExcludeMatchTypeGlob)
+	} else if n.Kind == yaml.MappingNode {
+		v.report(n, "exclude is missing the matchtype field")
+	}
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	if ok && matchType.Value == v1beta1.ExcludeMatchTypeGlob {
+		if r, ok := fields["regexp"]; ok {
+			v.report(r, "regexp can not be used with matchtype glob, use glob instead")
+		}
+		if g, ok := fields["glob"]; ok {
+			v.glob(g)
+		} else {
+			v.report(n, "exclude is missing the glob field")
+		}
+		return
+	}
+	if g, ok := fields["glob"]; ok {
+		v.report(g, "glob can only be used with matchtype glob")
+	}
+	if r, ok := fields["regexp"]; ok {
+		v.regExp(r, "regexp")
+	} else {
+		v.report(n, "exclude is missing the regexp field")
+	}
+}
+
+// glob checks that a string scalar holds a valid glob
+func (v *validator) glob(n *yaml.Node) {
+	var value string
+	if !v.scalar(n, "glob", "a string", &value) {
+		return
+	}
+	if len(value) == 0 {
+		v.report(n, "glob must not be empty")
+		return
+	}
+	if _, err := regexp.Compile(excludes.GlobRegExp(value)); err != nil {
+		v.report(n, "invalid glob %q: %s", value, err.Error())
+	}
+}
+
+func (v *validator) v1alpha1Groups(n *yaml.Node) {
+	descriptions := map[string]*yaml.Node{}
+	matchOrders := map[int]*yaml.Node{}
+	for _, g := range v.sequence(n, "groups") {
+		fields := v.mapping(g, "group", "description", "matchorder", "regexp", "sort")
+		if g.Kind != yaml.MappingNode {
+			continue
+		}
+
+		if d, ok := fields["description"]; ok {
+			var description string
+			if v.scalar(d, "description", "a string", &description) {
+				if len(description) == 0 {
+					v.report(d, "description must not be empty")
+				} else if first, ok := descriptions[description]; ok {
+					v.report(d, "duplicate description %q, already used on line %d", description, first.Line)
+				} else {
+					descriptions[description] = d
+				}
+			}
+		} else {
+			v.report(g, "group is missing the description field")
+		}
+
+		if m, ok := fields["matchorder"]; ok {
+			var matchOrder int
+			if v.scalar(m, "matchorder", "an integer", &matchOrder) {
+				if first, ok := matchOrders[matchOrder]; ok {
+					v.warn(m, "duplicate matchorder %d, already used on line %d", matchOrder, first.Line)
+				} else {
+					matchOrders[matchOrder] = m
+				}
+			}
+		} else {
+			v.report(g, "group is missing the matchorder field")
+		}
+
+		if r, ok := fields["regexp"]; ok {
+			v.regExps(r)
+		} else {
+			v.report(g, "group is missing the regexp field")
+		}
+
+		if s, ok := fields["sort"]; ok {
+			v.sort(s)
+		}
+	}
+}
+
+// v1beta1Groups validates the groups and returns the nodes of their names
+func (v *validator) v1beta1Groups(n *yaml.Node) map[string]*yaml.Node {
+	names := map[string]*yaml.Node{}
+	for _, g := range v.sequence(n, "groups") {
+		fields := v.mapping(g, "group", "name", "description", "regexp", "options")
+		if g.Kind != yaml.MappingNode {
+			continue
+		}
+
+		if nameNode, ok := fields["name"]; ok {
+			var name string
+			if v.scalar(nameNode, "name", "a string", &name) {
+				if len(name) == 0 {
+					v.report(nameNode, "name must not be empty")
+				} else if first, ok := names[name]; ok {
+					v.report(nameNode, "duplicate name %q, already used on line %d", name, first.Line)
+				} else {
+					names[name] = nameNode
+				}
+			}
+		} else {
+			v.report(g, "group is missing the name field")
+		}
+
+		if d, ok := fields["description"]; ok {
+			var description string
+			v.scalar(d, "description", "a string", &description)
+		}
+
+		if r, ok := fields["regexp"]; ok {
+			v.regExps(r)
+		} else {
+			v.report(g, "group is missing the regexp field")
+		}
+
+		if options, ok := fields["options"]; ok {
+			optionFields := v.mapping(options, "group options", "sort")
+			if s, ok := optionFields["sort"]; ok {
+				v.sort(s)
+			}
+		}
+	}
+	return names
+}
+
+// regExps checks that the node is a non empty list of Regular Expressions
+func (v *validator) regExps(n *yaml.Node) {
+	items := v.sequence(n, "regexp")
+	if n.Kind == yaml.SequenceNode && len(items) == 0 {
+		v.report(n, "regexp must hold at least one Regular Expression")
+	}
+	for _, item := range items {
+		v.regExp(item, "regexp")
+	}
+}
+
+func (v *validator) sort(n *yaml.Node) {
+	fields := v.mapping(n, "sort", "order", "named", "separateblankanddot")
+	if order, ok := fields["order"]; ok {
+		v.oneOf(order, "sort order", v1alpha1.SortOrderLexical, v1alpha1.SortOrderSegments, v1alpha1.SortOrderCaseInsensitive)
+	}
+	if named, ok := fields["named"]; ok {
+		v.oneOf(named, "sort named", v1alpha1.SortNamedFirst, v1alpha1.SortNamedLast)
+	}
+	if separate, ok := fields["separateblankanddot"]; ok {
+		var b bool
+		v.scalar(separate, "separateblankanddot", "a boolean", &b)
+	}
+}
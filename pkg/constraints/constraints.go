@@ -0,0 +1,92 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package constraints
+
+import (
+	"bytes"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Context decides whether a Go file participates in a build for an operating
+// system, an architecture and a set of build tags. It uses the rules of the go
+// command for file name suffixes and //go:build lines through go/build.
+type Context struct {
+	ctxt build.Context
+}
+
+// New returns the Context for the operating system, architecture and build
+// tags, an empty goos or goarch defaults to the one of the go command. Like the
+// go command, cgo follows the CGO_ENABLED environment variable when it is set
+// and is otherwise only enabled for the operating system and architecture of
+// the go command when it supports cgo. The cgo build tag is satisfied when cgo
+// is enabled or when it is one of the tags.
+func New(goos string, goarch string, tags []string) *Context {
+	ctxt := build.Default
+	if len(goos) != 0 {
+		ctxt.GOOS = goos
+	}
+	if len(goarch) != 0 {
+		ctxt.GOARCH = goarch
+	}
+	ctxt.BuildTags = tags
+	switch os.Getenv("CGO_ENABLED") {
+	case "1":
+		ctxt.CgoEnabled = true
+	case "0":
+		ctxt.CgoEnabled = false
+	default:
+		ctxt.CgoEnabled = build.Default.CgoEnabled && ctxt.GOOS == build.Default.GOOS && ctxt.GOARCH == build.Default.GOARCH
+	}
+	return &Context{ctxt: ctxt}
+}
+
+// Match reports whether the Go file at path with the source src participates
+// in the build. The path is used for its file name suffix and for error
+// messages, the file itself is not read. A file that imports "C" does not
+// participate in the build when cgo is disabled.
+func (c *Context) Match(path string, src []byte) (bool, error) {
+	ctxt := c.ctxt
+	ctxt.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(src)), nil
+	}
+	match, err := ctxt.MatchFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil || !match || ctxt.CgoEnabled {
+		return match, err
+	}
+	return !importsC(path, src), nil
+}
+
+// importsC reports whether the Go source src imports "C". Source whose imports
+// do not parse is reported as not importing "C", so that the error is reported
+// when the file is organized.
+func importsC(path string, src []byte) bool {
+	f, err := parser.ParseFile(token.NewFileSet(), path, src, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+	for _, spec := range f.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil && p == "C" {
+			return true
+		}
+	}
+	return false
+}
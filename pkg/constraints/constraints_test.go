@@ -0,0 +1,218 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package constraints
+
+import (
+	"go/build"
+	"runtime"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	linux := New("linux", "amd64", nil)
+	tests := []struct {
+		name    string
+		context *Context
+		path    string
+		src     string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "unconstrained file",
+			context: linux,
+			path:    "main.go",
+			src:     "package main\n",
+			want:    true,
+		},
+		{
+			name:    "matching file name suffix",
+			context: linux,
+			path:    "dir/file_linux_amd64_test.go",
+			src:     "package main\n",
+			want:    true,
+		},
+		{
+			name:    "other operating system in the file name",
+			context: linux,
+			path:    "file_windows.go",
+			src:     "package main\n",
+		},
+		{
+			name:    "other architecture in the file name",
+			context: linux,
+			path:    "file_linux_arm64.go",
+			src:     "package main\n",
+		},
+		{
+			name:    "operating system without a prefix is not a suffix",
+			context: linux,
+			path:    "windows.go",
+			src:     "package main\n",
+			want:    true,
+		},
+		{
+			name:    "go:build line",
+			context: linux,
+			path:    "file.go",
+			src:     "//go:build unix && !arm64\n\npackage main\n",
+			want:    true,
+		},
+		{
+			name:    "go:build line with a missing tag",
+			context: linux,
+			path:    "file.go",
+			src:     "//go:build linux && integration\n\npackage main\n",
+		},
+		{
+			name:    "go:build line with a tag",
+			context: New("linux", "amd64", []string{"integration"}),
+			path:    "file.go",
+			src:     "//go:build linux && integration\n\npackage main\n",
+			want:    true,
+		},
+		{
+			name:    "go:build line takes precedence over +build lines",
+			context: linux,
+			path:    "file.go",
+			src:     "//go:build linux\n// +build windows\n\npackage main\n",
+			want:    true,
+		},
+		{
+			name:    "all +build lines have to be satisfied",
+			context: linux,
+			path:    "file.go",
+			src:     "// +build linux darwin\n// +build arm64\n\npackage main\n",
+		},
+		{
+			name:    "android satisfies linux",
+			context: New("android", "arm64", nil),
+			path:    "file_linux.go",
+			src:     "//go:build linux\n\npackage main\n",
+			want:    true,
+		},
+		{
+			name:    "go:build line in the package doc comment",
+			context: linux,
+			path:    "file.go",
+			src:     "//go:build windows\npackage main\n",
+			want:    false,
+		},
+		{
+			name:    "+build line in the package doc comment is ignored",
+			context: linux,
+			path:    "file.go",
+			src:     "// +build windows\npackage main\n",
+			want:    true,
+		},
+		{
+			name:    "invalid go:build line",
+			context: linux,
+			path:    "file.go",
+			src:     "//go:build linux &&\n\npackage main\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.context.Match(tt.path, []byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Match() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("CGO_ENABLED", "")
+	c := New("plan9", "", []string{"integration"})
+	if c.ctxt.GOOS != "plan9" || len(c.ctxt.GOARCH) == 0 || c.ctxt.CgoEnabled || len(c.ctxt.BuildTags) != 1 {
+		t.Errorf("New() = %+v, want plan9 with the default architecture and without cgo", c.ctxt)
+	}
+}
+
+func TestMatchCgo(t *testing.T) {
+	otherArch := "arm64"
+	if runtime.GOARCH == otherArch {
+		otherArch = "amd64"
+	}
+	cgo := "package main\n\nimport \"C\"\n"
+	tests := []struct {
+		name       string
+		cgoEnabled string
+		goos       string
+		goarch     string
+		src        string
+		want       bool
+	}{
+		{
+			name:   "go command operating system and architecture",
+			goos:   build.Default.GOOS,
+			goarch: build.Default.GOARCH,
+			src:    cgo,
+			want:   build.Default.CgoEnabled,
+		},
+		{
+			name:   "other operating system",
+			goos:   "windows",
+			goarch: build.Default.GOARCH,
+			src:    cgo,
+		},
+		{
+			name:   "other architecture",
+			goos:   build.Default.GOOS,
+			goarch: otherArch,
+			src:    cgo,
+		},
+		{
+			name:       "other operating system with CGO_ENABLED=1",
+			cgoEnabled: "1",
+			goos:       "windows",
+			goarch:     build.Default.GOARCH,
+			src:        cgo,
+			want:       true,
+		},
+		{
+			name:       "go command operating system and architecture with CGO_ENABLED=0",
+			cgoEnabled: "0",
+			goos:       build.Default.GOOS,
+			goarch:     build.Default.GOARCH,
+			src:        cgo,
+		},
+		{
+			name:   "other operating system without cgo",
+			goos:   "windows",
+			goarch: build.Default.GOARCH,
+			src:    "package main\n\nimport \"fmt\"\n",
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CGO_ENABLED", tt.cgoEnabled)
+			got, err := New(tt.goos, tt.goarch, nil).Match("file.go", []byte(tt.src))
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
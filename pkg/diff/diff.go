@@ -0,0 +1,243 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package diff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// contextLines is the number of unchanged lines that surround each hunk
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single line level edit, oldLine and newLine are the zero based
+// indexes of the line in the old and new content
+type op struct {
+	kind    opKind
+	oldLine int
+	newLine int
+}
+
+// Unified returns a unified diff that transforms old into new. The path is used
+// for both the --- and +++ headers, without timestamps, so that the output can
+// be applied from the same directory with `patch -p0` or `git apply -p0`. An
+// empty slice is returned when old and new are equal.
+func Unified(path string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	a := splitLines(old)
+	b := splitLines(new)
+	ops := edits(a, b)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+	for _, h := range hunks(ops) {
+		oldStart, oldCount, newStart, newCount := 0, 0, 0, 0
+		for i, o := range h {
+			if i == 0 {
+				oldStart, newStart = o.oldLine, o.newLine
+			}
+			if o.kind != opInsert {
+				oldCount++
+			}
+			if o.kind != opDelete {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, o := range h {
+			switch o.kind {
+			case opEqual:
+				writeLine(&out, ' ', a[o.oldLine])
+			case opDelete:
+				writeLine(&out, '-', a[o.oldLine])
+			case opInsert:
+				writeLine(&out, '+', b[o.newLine])
+			}
+		}
+	}
+	return out.Bytes()
+}
+
+// hunkRange formats one side of a hunk header, an empty range refers to the
+// line before the hunk as expected by patch and git apply
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writeLine writes a single diff line and marks lines that are missing their
+// trailing newline
+func writeLine(out *bytes.Buffer, prefix byte, line string) {
+	out.WriteByte(prefix)
+	out.WriteString(line)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// splitLines splits the content into lines, each line keeps its trailing newline
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:i+1]))
+		content = content[i+1:]
+	}
+	return lines
+}
+
+// hunks groups the edits into hunks, each surrounded by at most contextLines
+// unchanged lines. Changes that are closer together than twice the context
+// are merged into the same hunk.
+func hunks(ops []op) [][]op {
+	var result [][]op
+	start, end := -1, -1
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		if start != -1 && i-end > 2*contextLines {
+			result = append(result, ops[start:min(end+contextLines+1, len(ops))])
+			start = -1
+		}
+		if start == -1 {
+			start = max(i-contextLines, 0)
+		}
+		end = i
+	}
+	if start != -1 {
+		result = append(result, ops[start:min(end+contextLines+1, len(ops))])
+	}
+	return result
+}
+
+// edits computes the shortest edit script between a and b using the Myers
+// difference algorithm. Common leading and trailing lines are stripped first
+// since import changes are usually confined to a small part of the file.
+func edits(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, oldLine: i, newLine: i})
+	}
+	for _, o := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		o.oldLine += prefix
+		o.newLine += prefix
+		ops = append(ops, o)
+	}
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, op{kind: opEqual, oldLine: len(a) - i, newLine: len(b) - i})
+	}
+	return ops
+}
+
+// myers returns the edit script for a and b, see "An O(ND) Difference Algorithm
+// and Its Variations" by Eugene W. Myers
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds the furthest reaching x values for the diagonals -d-1...d+1
+	// as they were before step d was taken
+	var trace [][]int
+
+	done := false
+	for d := 0; d <= n+m && !done; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edits
+	var reversed []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{kind: opEqual, oldLine: x, newLine: y})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, op{kind: opInsert, oldLine: x, newLine: y})
+		} else {
+			x--
+			reversed = append(reversed, op{kind: opDelete, oldLine: x, newLine: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, op{kind: opEqual, oldLine: x, newLine: y})
+	}
+
+	ops := make([]op, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		ops = append(ops, reversed[i])
+	}
+	return ops
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	type args struct {
+		path string
+		old  string
+		new  string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "no changes",
+			args: args{
+				path: "main.go",
+				old:  "package main\n",
+				new:  "package main\n",
+			},
+			want: "",
+		},
+		{
+			name: "reordered imports",
+			args: args{
+				path: "pkg/example/example.go",
+				old: `package example
+
+import (
+	"github.com/example/module/pkg/one"
+	"fmt"
+	"os"
+)
+
+func main() {}
+`,
+				new: `package example
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/example/module/pkg/one"
+)
+
+func main() {}
+`,
+			},
+			want: "--- pkg/example/example.go\n" +
+				"+++ pkg/example/example.go\n" +
+				"@@ -1,9 +1,10 @@\n" +
+				" package example\n" +
+				" \n" +
+				" import (\n" +
+				"-\t\"github.com/example/module/pkg/one\"\n" +
+				" \t\"fmt\"\n" +
+				" \t\"os\"\n" +
+				"+\n" +
+				"+\t\"github.com/example/module/pkg/one\"\n" +
+				" )\n" +
+				" \n" +
+				" func main() {}\n",
+		},
+		{
+			name: "separate hunks",
+			args: args{
+				path: "main.go",
+				old:  "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n",
+				new:  "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\n",
+			},
+			want: `--- main.go
++++ main.go
+@@ -1,4 +1,4 @@
+-a
++A
+ b
+ c
+ d
+@@ -9,4 +9,4 @@
+ i
+ j
+ k
+-l
++L
+`,
+		},
+		{
+			name: "missing trailing newline",
+			args: args{
+				path: "main.go",
+				old:  "package main",
+				new:  "package main\n",
+			},
+			want: `--- main.go
++++ main.go
+@@ -1,1 +1,1 @@
+-package main
+\ No newline at end of file
++package main
+`,
+		},
+		{
+			name: "empty old content",
+			args: args{
+				path: "main.go",
+				old:  "",
+				new:  "package main\n",
+			},
+			want: `--- main.go
++++ main.go
+@@ -0,0 +1,1 @@
++package main
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified(tt.args.path, []byte(tt.args.old), []byte(tt.args.new))
+			if string(got) != tt.want {
+				t.Errorf("Unified() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
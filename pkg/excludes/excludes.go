@@ -1,25 +1,184 @@
 package excludes
 
 import (
+	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 
-	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
 )
 
-// Build assembles the Regular Expressions that are used to exclude files and folders
-// based on their name or path
-func Build(excludes []v1alpha1.Exclude) (*regexp.Regexp, *regexp.Regexp) {
+// Matcher decides whether a file or folder is excluded from being organized
+type Matcher interface {
+	// Match returns true if the file or folder at path is excluded, the path is
+	// either absolute or relative to the current directory
+	Match(path string, isDir bool) bool
+}
+
+// Any is a Matcher that excludes the files and folders that any of its
+// Matchers excludes
+type Any []Matcher
+
+// Match returns true if any of the Matchers matches the path
+func (a Any) Match(path string, isDir bool) bool {
+	for _, m := range a {
+		if m.Match(path, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// NameMatcher excludes the files and folders whose name matches the RegExp
+type NameMatcher struct {
+	RegExp *regexp.Regexp
+}
+
+// Match returns true if the name of the file or folder matches the RegExp
+func (m NameMatcher) Match(path string, isDir bool) bool {
+	return m.RegExp.MatchString(filepath.Base(path))
+}
+
+// PathMatcher excludes the files and folders whose path relative to the
+// BasePath matches the RegExp
+type PathMatcher struct {
+	RegExp   *regexp.Regexp
+	BasePath string
+}
+
+// Match returns true if the relative path of the file or folder matches the
+// RegExp
+func (m PathMatcher) Match(path string, isDir bool) bool {
+	return m.RegExp.MatchString(relativePath(m.BasePath, path))
+}
+
+// GlobMatcher excludes the files and folders whose path relative to the
+// BasePath matches the Glob
+type GlobMatcher struct {
+	Glob     string
+	BasePath string
+	regExp   *regexp.Regexp
+}
+
+// NewGlobMatcher returns a GlobMatcher for the glob, an error is returned when
+// the glob holds an invalid character class
+func NewGlobMatcher(glob string, basePath string) (*GlobMatcher, error) {
+	r, err := regexp.Compile(`^` + GlobRegExp(glob) + `$`)
+	if err != nil {
+		return nil, err
+	}
+	return &GlobMatcher{Glob: glob, BasePath: basePath, regExp: r}, nil
+}
+
+// Match returns true if the relative path of the file or folder matches the
+// Glob
+func (m *GlobMatcher) Match(path string, isDir bool) bool {
+	return m.regExp.MatchString(relativePath(m.BasePath, path))
+}
+
+// relativePath returns the path relative to the basePath using forward slashes,
+// the path is returned as is when that is not possible
+func relativePath(basePath string, path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(basePath, absPath)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// GlobRegExp converts a glob to the body of a Regular Expression. A * matches
+// any number of characters except /, a ? matches a single character except /
+// and [...] matches a character class that is negated by a leading !. A **/
+// matches zero or more directories and a trailing /** matches everything inside
+// of a directory. A \ escapes the character that follows it.
+func GlobRegExp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			b.WriteString(`(?:.*/)?`)
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob) && i > 0 && glob[i-1] == '/':
+			b.WriteString(`.*`)
+			i++
+		case c == '*':
+			b.WriteString(`[^/]*`)
+		case c == '?':
+			b.WriteString(`[^/]`)
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Build assembles the Matchers that are used to exclude files and folders based
+// on their name, path or a glob. Path Regular Expressions and globs are matched
+// against the path relative to the basePath. The name and path Regular
+// Expressions are combined into a single Matcher each, nothing is returned for
+// a match type without excludes. An error identifying the offending Exclude is
+// returned when one of the Regular Expressions or globs does not compile.
+func Build(excludes []v1beta1.Exclude, basePath string) (Any, error) {
 	var excludeByPath []string
 	var excludeByName []string
+	matchers := Any{}
 
-	for _, exclude := range excludes {
+	for i, exclude := range excludes {
+		if exclude.MatchType == v1beta1.ExcludeMatchTypeGlob {
+			if len(exclude.Glob) == 0 {
+				return nil, fmt.Errorf("exclude %d (matchtype %s) is missing the glob", i+1, exclude.MatchType)
+			}
+			m, err := NewGlobMatcher(exclude.Glob, basePath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %q in exclude %d (matchtype %s): %s", exclude.Glob, i+1, exclude.MatchType, err.Error())
+			}
+			matchers = append(matchers, m)
+			continue
+		}
+		if _, err := regexp.Compile(exclude.RegExp); err != nil {
+			return nil, fmt.Errorf("invalid regexp %q in exclude %d (matchtype %s): %s", exclude.RegExp, i+1, exclude.MatchType, err.Error())
+		}
 		switch exclude.MatchType {
-		case v1alpha1.ExcludeMatchTypeName:
+		case v1beta1.ExcludeMatchTypeName:
 			excludeByName = append(excludeByName, exclude.RegExp)
-		case v1alpha1.ExcludeMatchTypeRelativePath:
+		case v1beta1.ExcludeMatchTypeRelativePath:
 			excludeByPath = append(excludeByPath, exclude.RegExp)
 		}
 	}
-	return regexp.MustCompile(strings.Join(excludeByName, "|")), regexp.MustCompile(strings.Join(excludeByPath, "|"))
+	if len(excludeByName) != 0 {
+		excludeByNameRegExp, err := regexp.Compile(strings.Join(excludeByName, "|"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid name excludes: %s", err.Error())
+		}
+		matchers = append(matchers, NameMatcher{RegExp: excludeByNameRegExp})
+	}
+	if len(excludeByPath) != 0 {
+		excludeByPathRegExp, err := regexp.Compile(strings.Join(excludeByPath, "|"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid path excludes: %s", err.Error())
+		}
+		matchers = append(matchers, PathMatcher{RegExp: excludeByPathRegExp, BasePath: basePath})
+	}
+	return matchers, nil
 }
@@ -1,27 +1,29 @@
 package excludes
 
 import (
-	"reflect"
-	"regexp"
+	"path/filepath"
 	"testing"
 
-	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
 )
 
 func TestBuild(t *testing.T) {
+	basePath := filepath.FromSlash("/module")
 	type args struct {
-		excludes []v1alpha1.Exclude
+		excludes []v1beta1.Exclude
 	}
 	tests := []struct {
-		name             string
-		args             args
-		wantNameMatchers *regexp.Regexp
-		wantPathMatchers *regexp.Regexp
+		name         string
+		args         args
+		wantMatchers int
+		wantExcluded []string
+		wantIncluded []string
+		wantErr      string
 	}{
 		{
 			name: "only name excludes",
 			args: args{
-				excludes: []v1alpha1.Exclude{
+				excludes: []v1beta1.Exclude{
 					{
 						MatchType: "name",
 						RegExp:    "^name-one$",
@@ -32,13 +34,14 @@ func TestBuild(t *testing.T) {
 					},
 				},
 			},
-			wantNameMatchers: regexp.MustCompile(`^name-one$|^name-two$`),
-			wantPathMatchers: regexp.MustCompile(``),
+			wantMatchers: 1,
+			wantExcluded: []string{"name-one", "pkg/name-two"},
+			wantIncluded: []string{"name-three", "name-one/file.go"},
 		},
 		{
 			name: "only path excludes",
 			args: args{
-				excludes: []v1alpha1.Exclude{
+				excludes: []v1beta1.Exclude{
 					{
 						MatchType: "path",
 						RegExp:    "^path-one$",
@@ -49,13 +52,14 @@ func TestBuild(t *testing.T) {
 					},
 				},
 			},
-			wantNameMatchers: regexp.MustCompile(``),
-			wantPathMatchers: regexp.MustCompile(`^path-one$|^path-two$`),
+			wantMatchers: 1,
+			wantExcluded: []string{"path-one", "path-two"},
+			wantIncluded: []string{"pkg/path-one"},
 		},
 		{
 			name: "name and path excludes",
 			args: args{
-				excludes: []v1alpha1.Exclude{
+				excludes: []v1beta1.Exclude{
 					{
 						MatchType: "name",
 						RegExp:    "^name-one$",
@@ -74,18 +78,102 @@ func TestBuild(t *testing.T) {
 					},
 				},
 			},
-			wantNameMatchers: regexp.MustCompile(`^name-one$|^name-two$`),
-			wantPathMatchers: regexp.MustCompile(`^path-one$|^path-two$`),
+			wantMatchers: 2,
+			wantExcluded: []string{"pkg/name-one", "path-two"},
+			wantIncluded: []string{"pkg/path-two"},
+		},
+		{
+			name: "glob excludes",
+			args: args{
+				excludes: []v1beta1.Exclude{
+					{
+						MatchType: "glob",
+						Glob:      "vendor",
+					},
+					{
+						MatchType: "glob",
+						Glob:      "**/*.pb.go",
+					},
+					{
+						MatchType: "glob",
+						Glob:      "hack/**",
+					},
+				},
+			},
+			wantMatchers: 3,
+			wantExcluded: []string{"vendor", "types.pb.go", "api/v1/types.pb.go", "hack/tools/tools.go"},
+			wantIncluded: []string{"pkg/vendor", "api/v1/types.go", "hack"},
+		},
+		{
+			name:         "no excludes",
+			wantMatchers: 0,
+			wantIncluded: []string{"vendor"},
+		},
+		{
+			name: "invalid regexp",
+			args: args{
+				excludes: []v1beta1.Exclude{
+					{
+						MatchType: "name",
+						RegExp:    "^name-one$",
+					},
+					{
+						MatchType: "path",
+						RegExp:    "^path-(one$",
+					},
+				},
+			},
+			wantErr: "invalid regexp \"^path-(one$\" in exclude 2 (matchtype path): error parsing regexp: missing closing ): `^path-(one$`",
+		},
+		{
+			name: "invalid glob",
+			args: args{
+				excludes: []v1beta1.Exclude{
+					{
+						MatchType: "glob",
+						Glob:      "[z-a].go",
+					},
+				},
+			},
+			wantErr: "invalid glob \"[z-a].go\" in exclude 1 (matchtype glob): error parsing regexp: invalid character class range: `z-a`",
+		},
+		{
+			name: "missing glob",
+			args: args{
+				excludes: []v1beta1.Exclude{
+					{
+						MatchType: "glob",
+						RegExp:    "^vendor$",
+					},
+				},
+			},
+			wantErr: "exclude 1 (matchtype glob) is missing the glob",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotNameMatcher, gotPathMatcher := Build(tt.args.excludes)
-			if !reflect.DeepEqual(gotNameMatcher, tt.wantNameMatchers) {
-				t.Errorf("Build() gotNameMatcher = %v, want %v", gotNameMatcher, tt.wantNameMatchers)
+			got, err := Build(tt.args.excludes, basePath)
+			if len(tt.wantErr) != 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if len(got) != tt.wantMatchers {
+				t.Errorf("Build() returned %d matchers, want %d", len(got), tt.wantMatchers)
+			}
+			for _, path := range tt.wantExcluded {
+				if !got.Match(filepath.Join(basePath, filepath.FromSlash(path)), false) {
+					t.Errorf("Build() does not exclude %s", path)
+				}
 			}
-			if !reflect.DeepEqual(gotPathMatcher, tt.wantPathMatchers) {
-				t.Errorf("Build() gotPathMatcher = %v, want %v", gotPathMatcher, tt.wantPathMatchers)
+			for _, path := range tt.wantIncluded {
+				if got.Match(filepath.Join(basePath, filepath.FromSlash(path)), false) {
+					t.Errorf("Build() excludes %s", path)
+				}
 			}
 		})
 	}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package goio is the library interface of the Go Imports Organizer, it allows
+// other tools to organize the imports of Go source without shelling out to the
+// goio command.
+package goio
+
+import (
+	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/groups"
+	"github.com/go-imports-organizer/goio/pkg/imports"
+	"github.com/go-imports-organizer/goio/pkg/module"
+)
+
+// Organize returns src with its imports organized according to the v1alpha1
+// cfg. The filename is only used in error messages and moduleName is the name
+// of the module that src belongs to, it is used for the %{module}% group macro.
+// The cfg is converted with v1beta1.ConvertFromV1alpha1 and organized like
+// OrganizeV1beta1 does. The cfg is not modified and Organize is safe for
+// concurrent use.
+func Organize(src []byte, filename string, cfg v1alpha1.Config, moduleName string) ([]byte, error) {
+	return OrganizeV1beta1(src, filename, v1beta1.ConvertFromV1alpha1(cfg), moduleName)
+}
+
+// OrganizeV1beta1 returns src with its imports organized according to the
+// v1beta1 cfg. The filename is only used in error messages and moduleName is
+// the name of the module that src belongs to, it is used for the %{module}%
+// group macro. Since the go.mod file of the module is not read the %{stdlib}%
+// group macro matches every standard library package that goio knows of and
+// the %{direct}%, %{indirect}%, %{replaced}% and %{workspace}% group macros
+// match nothing. Generated files and files with a //goio:ignore directive are
+// organized as well, skipping them is left to the caller, and the generated
+// option of the cfg is not used. The cfg is not modified and OrganizeV1beta1 is
+// safe for concurrent use. An error is returned when one of the groups of the
+// cfg holds an invalid Regular Expression.
+func OrganizeV1beta1(src []byte, filename string, cfg v1beta1.Config, moduleName string) ([]byte, error) {
+	groupRegExpMatchers, displayOrder, err := groups.Build(cfg.Groups, cfg.MatchOrder, module.Module{Name: moduleName})
+	if err != nil {
+		return nil, err
+	}
+	return imports.Organize(filename, src, groupRegExpMatchers, displayOrder, cfg.Options.KeepImportDeclarations)
+}
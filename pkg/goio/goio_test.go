@@ -0,0 +1,209 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package goio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+)
+
+func TestOrganize(t *testing.T) {
+	cfg := v1alpha1.Config{
+		Groups: []v1alpha1.Group{
+			{
+				Description: "standard",
+				MatchOrder:  1,
+				RegExp:      []string{`^[a-zA-Z0-9\/]+$`},
+			},
+			{
+				Description: "other",
+				MatchOrder:  2,
+				RegExp:      []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+			},
+			{
+				Description: "module",
+				MatchOrder:  0,
+				RegExp:      []string{"%{module}%"},
+			},
+		},
+	}
+	src := "package example\n\nimport (\n\t\"github.com/example/module/pkg/one\"\n\t\"github.com/other/module/pkg/two\"\n\t\"fmt\"\n)\n"
+	want := "package example\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/other/module/pkg/two\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n"
+	before := append([]v1alpha1.Group(nil), cfg.Groups...)
+	got, err := Organize([]byte(src), "example.go", cfg, "github.com/example/module")
+	if err != nil {
+		t.Fatalf("Organize() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Organize() = %s, want %s", got, want)
+	}
+	if !reflect.DeepEqual(cfg.Groups, before) {
+		t.Errorf("Organize() modified the configuration groups = %v, want %v", cfg.Groups, before)
+	}
+}
+
+func TestOrganizeV1beta1(t *testing.T) {
+	cfg := v1beta1.Config{
+		Groups: []v1beta1.Group{
+			{
+				Name:   "standard",
+				RegExp: []string{`^[a-zA-Z0-9\/]+$`},
+			},
+			{
+				Name:   "other",
+				RegExp: []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+			},
+			{
+				Name:   "module",
+				RegExp: []string{"%{module}%"},
+			},
+		},
+		MatchOrder: []string{"module", "standard", "other"},
+	}
+	type args struct {
+		src        string
+		moduleName string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "organizes generated files",
+			args: args{
+				src: `// Code generated by generator. DO NOT EDIT.
+
+package example
+
+import (
+	"github.com/example/module/pkg/one"
+	"fmt"
+)
+`,
+				moduleName: "github.com/example/module",
+			},
+			want: `// Code generated by generator. DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+
+	"github.com/example/module/pkg/one"
+)
+`,
+		},
+		{
+			name: "organizes imports",
+			args: args{
+				src: `package example
+
+import (
+	"fmt"
+	"github.com/example/module/pkg/one"
+	"github.com/other/module/pkg/two"
+	"os"
+)
+`,
+				moduleName: "github.com/example/module",
+			},
+			want: `package example
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/other/module/pkg/two"
+
+	"github.com/example/module/pkg/one"
+)
+`,
+		},
+		{
+			name: "module name changes the module group",
+			args: args{
+				src: `package example
+
+import (
+	"fmt"
+	"github.com/example/module/pkg/one"
+	"github.com/other/module/pkg/two"
+	"os"
+)
+`,
+				moduleName: "github.com/other/module",
+			},
+			want: `package example
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/example/module/pkg/one"
+
+	"github.com/other/module/pkg/two"
+)
+`,
+		},
+		{
+			name: "invalid source",
+			args: args{
+				src:        "package example\n\nimport (\n",
+				moduleName: "github.com/example/module",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]v1beta1.Group(nil), cfg.Groups...)
+			got, err := OrganizeV1beta1([]byte(tt.args.src), "example.go", cfg, tt.args.moduleName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OrganizeV1beta1() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("OrganizeV1beta1() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(cfg.Groups, before) {
+				t.Errorf("OrganizeV1beta1() modified the configuration groups = %v, want %v", cfg.Groups, before)
+			}
+		})
+	}
+}
+
+func TestOrganizeInvalidRegExp(t *testing.T) {
+	cfg := v1beta1.Config{
+		Groups: []v1beta1.Group{
+			{
+				Name:   "standard",
+				RegExp: []string{`^[a-zA-Z0-9\/+$`},
+			},
+		},
+	}
+	got, err := OrganizeV1beta1([]byte("package example\n"), "example.go", cfg, "github.com/example/module")
+	if err == nil {
+		t.Fatalf("OrganizeV1beta1() = %s, want an error", got)
+	}
+	if !strings.Contains(err.Error(), `in group "standard"`) {
+		t.Errorf("OrganizeV1beta1() error = %v, want it to name the group", err)
+	}
+}
@@ -7,29 +7,189 @@ import (
 	"strings"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
-	"github.com/go-imports-organizer/goio/pkg/sorter"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/module"
+	"github.com/go-imports-organizer/goio/pkg/stdlib"
 )
 
 // Build asembles the RegExpMatchers that are used to group imports and the
-// array that defines the display order for the groups in the import block
-func Build(groups []v1alpha1.Group, goModuleName string) ([]v1alpha1.RegExpMatcher, []string) {
+// array that defines the display order for the groups in the import block. The
+// groups are displayed in the order that they are defined in and matched in the
+// matchOrder, groups that are not part of the matchOrder are matched last. An
+// error identifying the offending Group is returned when one of its Regular
+// Expressions does not compile or the matchOrder references an unknown group.
+func Build(groups []v1beta1.Group, matchOrder []string, mod module.Module) ([]v1alpha1.RegExpMatcher, []string, error) {
 	groupRegExpMatchers := []v1alpha1.RegExpMatcher{}
 	displayOrder := []string{}
 
-	for _, group := range groups {
-		displayOrder = append(displayOrder, group.Description)
+	byName := map[string]int{}
+	for i, group := range groups {
+		displayOrder = append(displayOrder, group.Name)
+		byName[group.Name] = i
 	}
 
-	sort.Sort(sorter.SortGroupsByMatchOrder(groups))
+	ordered := []v1beta1.Group{}
+	matched := map[string]bool{}
+	for _, name := range matchOrder {
+		i, ok := byName[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("matchorder references unknown group %q", name)
+		}
+		if !matched[name] {
+			ordered = append(ordered, groups[i])
+			matched[name] = true
+		}
+	}
+	for _, group := range groups {
+		if !matched[group.Name] {
+			ordered = append(ordered, group)
+		}
+	}
 
-	for i := range groups {
-		r := strings.Join(groups[i].RegExp, "|")
-		r = strings.Replace(r, `%{module}%`, fmt.Sprintf("^%s", strings.ReplaceAll(strings.ReplaceAll(goModuleName, `.`, `\.`), `/`, `\/`)), -1)
+	macros := macros(mod)
+	for _, group := range ordered {
+		for _, r := range group.RegExp {
+			if _, err := regexp.Compile(macros.Replace(r)); err != nil {
+				return nil, nil, fmt.Errorf("invalid regexp %q in group %q: %s", r, group.Name, err.Error())
+			}
+		}
+		r := macros.Replace(strings.Join(group.RegExp, "|"))
+		compiled, err := regexp.Compile(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid regexp in group %q: %s", group.Name, err.Error())
+		}
 		groupRegExpMatchers = append(groupRegExpMatchers, v1alpha1.RegExpMatcher{
-			Bucket: groups[i].Description,
-			RegExp: regexp.MustCompile(r),
+			Bucket: group.Name,
+			RegExp: compiled,
+			Sort:   v1alpha1.Sort(group.Options.Sort),
 		},
 		)
 	}
-	return groupRegExpMatchers, displayOrder
+	return groupRegExpMatchers, displayOrder, nil
+}
+
+// macros returns the Replacer that expands the macros that can be used in the
+// Regular Expressions of a group. %{module}% matches the imports of the module
+// itself and %{stdlib}% matches the standard library packages of the Go version
+// of the module. %{direct}%, %{indirect}% and %{replaced}% match the packages of
+// the modules that the go.mod file requires directly, requires with an
+// // indirect comment and replaces. %{workspace}% matches the packages of the
+// modules of the go.work file that uses the module. A package belongs to the
+// module with the longest path that it starts with, so a package of a module
+// that is nested in another module is only matched by the macro of its own
+// module.
+func macros(mod module.Module) *strings.Replacer {
+	direct, indirect := []string{}, []string{}
+	known := []string{mod.Name}
+	for _, r := range mod.Requires {
+		if r.Indirect {
+			indirect = append(indirect, r.Path)
+		} else {
+			direct = append(direct, r.Path)
+		}
+		known = append(known, r.Path)
+	}
+	known = append(append(known, mod.Replaces...), mod.Workspace...)
+	return strings.NewReplacer(
+		`%{module}%`, fmt.Sprintf("^%s", strings.ReplaceAll(strings.ReplaceAll(mod.Name, `.`, `\.`), `/`, `\/`)),
+		`%{stdlib}%`, stdlib.RegExp(mod.GoVersion),
+		`%{direct}%`, modulesRegExp(direct, known),
+		`%{indirect}%`, modulesRegExp(indirect, known),
+		`%{replaced}%`, modulesRegExp(mod.Replaces, known),
+		`%{workspace}%`, modulesRegExp(mod.Workspace, known),
+	)
+}
+
+// modulesRegExp returns a Regular Expression that matches the packages of the
+// modules, except for the packages of the known modules that are nested in one
+// of the modules without being one of them. A Regular Expression that never
+// matches is returned when there are no modules.
+func modulesRegExp(modules []string, known []string) string {
+	if len(modules) == 0 {
+		return `[^\x00-\x{10FFFF}]`
+	}
+	isModule := map[string]bool{}
+	for _, m := range modules {
+		isModule[m] = true
+	}
+	simple, nested := []string{}, []string{}
+	for _, m := range modules {
+		t := &trie{}
+		for _, k := range known {
+			if rest, ok := strings.CutPrefix(k, m+"/"); ok && len(rest) != 0 && !isModule[k] {
+				t.insert(rest)
+			}
+		}
+		if len(t.children) == 0 {
+			simple = append(simple, regexp.QuoteMeta(m))
+			continue
+		}
+		nested = append(nested, fmt.Sprintf("%s(?:/%s)?$", regexp.QuoteMeta(m), t.exceptRegExp()))
+	}
+	if len(nested) == 0 {
+		return fmt.Sprintf("^(?:%s)(?:/|$)", strings.Join(simple, "|"))
+	}
+	if len(simple) != 0 {
+		nested = append([]string{fmt.Sprintf("(?:%s)(?:/|$)", strings.Join(simple, "|"))}, nested...)
+	}
+	return fmt.Sprintf("^(?:%s)", strings.Join(nested, "|"))
+}
+
+// trie is a prefix tree of the paths of nested modules relative to the module
+// that they are nested in
+type trie struct {
+	children map[rune]*trie
+	// end is set when the path of a nested module ends at the node
+	end bool
+}
+
+// insert adds the path to the trie
+func (t *trie) insert(path string) {
+	for _, r := range path {
+		if t.children == nil {
+			t.children = map[rune]*trie{}
+		}
+		if t.children[r] == nil {
+			t.children[r] = &trie{}
+		}
+		t = t.children[r]
+	}
+	t.end = true
+}
+
+// exceptRegExp returns a Regular Expression that matches the rest of a package
+// path up to its end, unless the package belongs to one of the nested modules
+// of the trie. Go Regular Expressions have no negative lookahead, so the rest is
+// matched rune by rune along the paths of the trie.
+func (t *trie) exceptRegExp() string {
+	alternatives := []string{}
+	excluded := map[rune]bool{}
+	if t.end {
+		// The path of a nested module ends here, neither it nor its packages
+		// below it belong to the module
+		excluded['/'] = true
+	} else {
+		alternatives = append(alternatives, "")
+	}
+	runes := []rune{}
+	for r := range t.children {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	for _, r := range runes {
+		if excluded[r] {
+			continue
+		}
+		excluded[r] = true
+		alternatives = append(alternatives, regexp.QuoteMeta(string(r))+t.children[r].exceptRegExp())
+	}
+	class := []string{}
+	for _, r := range runes {
+		class = append(class, fmt.Sprintf(`\x{%x}`, r))
+	}
+	if t.end && t.children['/'] == nil {
+		class = append(class, `/`)
+	}
+	alternatives = append(alternatives, fmt.Sprintf("[^%s].*", strings.Join(class, "")))
+	return fmt.Sprintf("(?:%s)", strings.Join(alternatives, "|"))
 }
@@ -8,38 +8,44 @@ import (
 	"testing"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/module"
+	"github.com/go-imports-organizer/goio/pkg/stdlib"
 )
 
 func TestBuild(t *testing.T) {
 	type args struct {
-		groups       []v1alpha1.Group
+		groups       []v1beta1.Group
+		matchOrder   []string
 		goModuleName string
+		goVersion    string
+		requires     []module.Require
+		replaces     []string
+		workspace    []string
 	}
 	tests := []struct {
 		name               string
 		args               args
 		wantRegExpMatchers []v1alpha1.RegExpMatcher
 		wantDisplayOrder   []string
+		wantErr            string
 	}{
 		{
 			name: "group one test",
 			args: args{
 				goModuleName: "github.com/example/module",
-				groups: []v1alpha1.Group{
+				groups: []v1beta1.Group{
 					{
-						MatchOrder:  0,
-						Description: "module",
-						RegExp:      []string{"%{module}%"},
+						Name:   "module",
+						RegExp: []string{"%{module}%"},
 					},
 					{
-						MatchOrder:  1,
-						Description: "standard",
-						RegExp:      []string{`^[a-zA-Z0-9\\/]+$`},
+						Name:   "standard",
+						RegExp: []string{`^[a-zA-Z0-9\\/]+$`},
 					},
 					{
-						MatchOrder:  2,
-						Description: "other",
-						RegExp:      []string{`[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/`},
+						Name:   "other",
+						RegExp: []string{`[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/`},
 					},
 				},
 			},
@@ -63,13 +69,246 @@ func TestBuild(t *testing.T) {
 				"module",
 			},
 		},
+		{
+			name: "match order references groups by name",
+			args: args{
+				goModuleName: "github.com/example/module",
+				groups: []v1beta1.Group{
+					{
+						Name:    "standard",
+						RegExp:  []string{`^[a-zA-Z0-9\\/]+$`},
+						Options: v1beta1.GroupOptions{Sort: v1beta1.Sort{Order: v1beta1.SortOrderSegments}},
+					},
+					{
+						Name:   "other",
+						RegExp: []string{`[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/`},
+					},
+					{
+						Name:   "module",
+						RegExp: []string{"%{module}%"},
+					},
+				},
+				matchOrder: []string{"module", "standard"},
+			},
+			wantRegExpMatchers: []v1alpha1.RegExpMatcher{
+				{
+					Bucket: "module",
+					RegExp: regexp.MustCompile(fmt.Sprintf("^%s", strings.ReplaceAll(strings.ReplaceAll(`github.com/example/module`, `.`, `\.`), `/`, `\/`))),
+				},
+				{
+					Bucket: "standard",
+					RegExp: regexp.MustCompile(`^[a-zA-Z0-9\\/]+$`),
+					Sort:   v1alpha1.Sort{Order: v1alpha1.SortOrderSegments},
+				},
+				{
+					Bucket: "other",
+					RegExp: regexp.MustCompile(`[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/`),
+				},
+			},
+		},
+		{
+			name: "stdlib macro",
+			args: args{
+				goModuleName: "github.com/example/module",
+				goVersion:    "1.21",
+				groups: []v1beta1.Group{
+					{
+						Name:   "standard",
+						RegExp: []string{"%{stdlib}%"},
+					},
+				},
+			},
+			wantRegExpMatchers: []v1alpha1.RegExpMatcher{
+				{
+					Bucket: "standard",
+					RegExp: regexp.MustCompile(stdlib.RegExp("1.21")),
+				},
+			},
+		},
+		{
+			name: "go.mod macros",
+			args: args{
+				goModuleName: "github.com/example/module",
+				requires: []module.Require{
+					{Path: "github.com/example/direct"},
+					{Path: "golang.org/x/mod"},
+					{Path: "github.com/example/indirect", Indirect: true},
+				},
+				replaces: []string{"github.com/example/replaced"},
+				groups: []v1beta1.Group{
+					{
+						Name:   "direct",
+						RegExp: []string{"%{direct}%"},
+					},
+					{
+						Name:   "indirect",
+						RegExp: []string{"%{indirect}%"},
+					},
+					{
+						Name:   "replaced",
+						RegExp: []string{"%{replaced}%"},
+					},
+				},
+				matchOrder: []string{"replaced", "direct", "indirect"},
+			},
+			wantRegExpMatchers: []v1alpha1.RegExpMatcher{
+				{
+					Bucket: "replaced",
+					RegExp: regexp.MustCompile(`^(?:github\.com/example/replaced)(?:/|$)`),
+				},
+				{
+					Bucket: "direct",
+					RegExp: regexp.MustCompile(`^(?:github\.com/example/direct|golang\.org/x/mod)(?:/|$)`),
+				},
+				{
+					Bucket: "indirect",
+					RegExp: regexp.MustCompile(`^(?:github\.com/example/indirect)(?:/|$)`),
+				},
+			},
+		},
+		{
+			name: "workspace macro",
+			args: args{
+				goModuleName: "github.com/example/module",
+				workspace:    []string{"github.com/example/module", "github.com/example/tools"},
+				groups: []v1beta1.Group{
+					{
+						Name:   "workspace",
+						RegExp: []string{"%{workspace}%"},
+					},
+				},
+			},
+			wantRegExpMatchers: []v1alpha1.RegExpMatcher{
+				{
+					Bucket: "workspace",
+					RegExp: regexp.MustCompile(`^(?:github\.com/example/module|github\.com/example/tools)(?:/|$)`),
+				},
+			},
+		},
+		{
+			name: "go.mod macros without requirements",
+			args: args{
+				goModuleName: "github.com/example/module",
+				groups: []v1beta1.Group{
+					{
+						Name:   "direct",
+						RegExp: []string{"%{direct}%"},
+					},
+				},
+			},
+			wantRegExpMatchers: []v1alpha1.RegExpMatcher{
+				{
+					Bucket: "direct",
+					RegExp: regexp.MustCompile(`[^\x00-\x{10FFFF}]`),
+				},
+			},
+		},
+		{
+			name: "match order references an unknown group",
+			args: args{
+				goModuleName: "github.com/example/module",
+				groups: []v1beta1.Group{
+					{
+						Name:   "standard",
+						RegExp: []string{`^[a-zA-Z0-9\\/]+$`},
+					},
+				},
+				matchOrder: []string{"module", "standard"},
+			},
+			wantErr: `matchorder references unknown group "module"`,
+		},
+		{
+			name: "invalid regexp",
+			args: args{
+				goModuleName: "github.com/example/module",
+				groups: []v1beta1.Group{
+					{
+						Name:   "module",
+						RegExp: []string{"%{module}%"},
+					},
+					{
+						Name:   "standard",
+						RegExp: []string{`^[a-zA-Z0-9\\/]+$`, `^[a-z`},
+					},
+				},
+			},
+			wantErr: "invalid regexp \"^[a-z\" in group \"standard\": error parsing regexp: missing closing ]: `[a-z`",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotRegExpMatchers, _ := Build(tt.args.groups, tt.args.goModuleName)
+			gotRegExpMatchers, _, err := Build(tt.args.groups, tt.args.matchOrder, module.Module{Name: tt.args.goModuleName, GoVersion: tt.args.goVersion, Requires: tt.args.requires, Replaces: tt.args.replaces, Workspace: tt.args.workspace})
+			if len(tt.wantErr) != 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
 			if !reflect.DeepEqual(gotRegExpMatchers, tt.wantRegExpMatchers) {
 				t.Errorf("Build() gotRegExpMatchers = %v, wantRegExpMatchers %v", gotRegExpMatchers, tt.wantRegExpMatchers)
 			}
 		})
 	}
 }
+
+func TestBuildNestedModules(t *testing.T) {
+	mod := module.Module{
+		Name: "github.com/example/module",
+		Requires: []module.Require{
+			{Path: "github.com/foo"},
+			{Path: "github.com/foo/bar", Indirect: true},
+			{Path: "github.com/foo/bar/baz"},
+			{Path: "github.com/example"},
+		},
+	}
+	groupList := []v1beta1.Group{
+		{
+			Name:   "direct",
+			RegExp: []string{"%{direct}%"},
+		},
+		{
+			Name:   "indirect",
+			RegExp: []string{"%{indirect}%"},
+		},
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "github.com/foo", want: "direct"},
+		{path: "github.com/foo/x", want: "direct"},
+		{path: "github.com/foo/ba", want: "direct"},
+		{path: "github.com/foo/barx", want: "direct"},
+		{path: "github.com/foo/bar", want: "indirect"},
+		{path: "github.com/foo/bar/x", want: "indirect"},
+		{path: "github.com/foo/bar/baz", want: "direct"},
+		{path: "github.com/foo/bar/baz/x", want: "direct"},
+		{path: "github.com/example/x", want: "direct"},
+		{path: "github.com/example/module/pkg/one", want: ""},
+		{path: "github.com/foobar", want: ""},
+	}
+	regExpMatchers, _, err := Build(groupList, nil, mod)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := []string{}
+			for _, r := range regExpMatchers {
+				if r.RegExp.MatchString(tt.path) {
+					got = append(got, r.Bucket)
+				}
+			}
+			want := []string{}
+			if len(tt.want) != 0 {
+				want = append(want, tt.want)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s is matched by %v, want %v", tt.path, got, want)
+			}
+		})
+	}
+}
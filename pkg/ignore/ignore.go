@@ -0,0 +1,157 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-imports-organizer/goio/pkg/excludes"
+)
+
+const (
+	// GitIgnore is the name of the ignore files of git
+	GitIgnore string = ".gitignore"
+	// GoioIgnore is the name of the ignore files that only apply to goio
+	GoioIgnore string = ".goioignore"
+)
+
+// Pattern is a single pattern of an ignore file
+type Pattern struct {
+	// regExp matches the paths relative to the directory of the ignore file
+	regExp *regexp.Regexp
+	// negate re-includes the paths that were ignored by earlier patterns
+	negate bool
+	// dirOnly only matches directories
+	dirOnly bool
+}
+
+// File holds the patterns of an ignore file
+type File struct {
+	// Dir is the directory that the patterns are relative to
+	Dir string
+	// Patterns are the patterns in the order they are defined in
+	Patterns []Pattern
+}
+
+// Load reads and parses the ignore file at path, a nil File is returned when it
+// does not exist
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read ignore file %s: %s", path, err.Error())
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine absolute path of %q: %s", path, err.Error())
+	}
+	return Parse(dir, data), nil
+}
+
+// Parse parses the .gitignore style patterns of an ignore file in the directory
+// dir. Blank lines and comments are skipped, a leading ! negates a pattern and a
+// trailing / only matches directories. A pattern without any other / matches
+// names at any depth, otherwise it is matched against the path relative to dir.
+// The wildcards *, ?, [...] and ** are supported.
+func Parse(dir string, data []byte) *File {
+	f := &File{Dir: dir}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if p, ok := parsePattern(scanner.Text()); ok {
+			f.Patterns = append(f.Patterns, p)
+		}
+	}
+	return f
+}
+
+// parsePattern parses a single line of an ignore file
+func parsePattern(line string) (Pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless they are escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return Pattern{}, false
+	}
+	p := Pattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if len(line) == 0 {
+		return Pattern{}, false
+	}
+	prefix := `(?:^|/)`
+	if strings.Contains(line, "/") {
+		prefix = `^`
+		line = strings.TrimPrefix(line, "/")
+	}
+	r, err := regexp.Compile(prefix + excludes.GlobRegExp(line) + `$`)
+	if err != nil {
+		return Pattern{}, false
+	}
+	p.regExp = r
+	return p, true
+}
+
+// Ignored reports whether the path is ignored by the ignore files, which are
+// given from the outermost to the innermost directory. The last pattern that
+// matches decides, so later patterns and deeper files take precedence.
+func Ignored(files []*File, path string, isDir bool) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	ignored := false
+	for _, f := range files {
+		rel, err := filepath.Rel(f.Dir, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, p := range f.Patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.regExp.MatchString(rel) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// Matcher excludes the files and folders that are ignored by its Files, which
+// are given from the outermost to the innermost directory
+type Matcher []*File
+
+// Match returns true if the path is ignored by the Files
+func (m Matcher) Match(path string, isDir bool) bool {
+	return Ignored(m, path, isDir)
+}
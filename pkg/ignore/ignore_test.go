@@ -0,0 +1,63 @@
+package ignore
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	files := []*File{
+		Parse(root, []byte(`# generated files
+*.pb.go
+!keep.pb.go
+/bin
+build/
+docs/**/*.go
+**/testdata
+foo/**
+\#hash.go
+trailing.go   
+[abc].go
+[!x]y.go
+`)),
+		Parse(filepath.Join(root, "nested"), []byte("!*.pb.go\nlocal.go\n")),
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "api/v1/types.pb.go", want: true},
+		{path: "api/v1/keep.pb.go", want: false},
+		{path: "api/v1/types.go", want: false},
+		{path: "bin", isDir: true, want: true},
+		{path: "cmd/bin", isDir: true, want: false},
+		{path: "build", want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "pkg/build", isDir: true, want: true},
+		{path: "docs/example.go", want: true},
+		{path: "docs/a/b/example.go", want: true},
+		{path: "pkg/docs/example.go", want: false},
+		{path: "testdata", isDir: true, want: true},
+		{path: "pkg/a/testdata", isDir: true, want: true},
+		{path: "foo/bar.go", want: true},
+		{path: "foo", isDir: true, want: false},
+		{path: "#hash.go", want: true},
+		{path: "trailing.go", want: true},
+		{path: "b.go", want: true},
+		{path: "d.go", want: false},
+		{path: "zy.go", want: true},
+		{path: "xy.go", want: false},
+		{path: "nested/types.pb.go", want: false},
+		{path: "nested/local.go", want: true},
+		{path: "local.go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Ignored(files, filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+				t.Errorf("Ignored(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package imports
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+const (
+	// RuleWrongGroup is used for import blocks where an import is not in the
+	// group that it belongs to
+	RuleWrongGroup string = "wrong-group"
+	// RuleWrongOrder is used for import blocks where the groups are correct but
+	// the imports within a group are not sorted
+	RuleWrongOrder string = "wrong-order"
+	// RuleMissingSeparator is used for import blocks where the imports are in
+	// the correct order but the groups are not separated by a single empty line
+	RuleMissingSeparator string = "missing-separator"
+)
+
+// Block is an import declaration that is not organized
+type Block struct {
+	// Rule is the most significant problem of the block, one of the Rule constants
+	Rule string
+	// StartLine and EndLine are the lines of the import declaration
+	StartLine int
+	EndLine   int
+	// Offset and Length are the byte range of the import declaration
+	Offset int
+	Length int
+	// Replacement is the organized import declaration
+	Replacement string
+}
+
+// parsedImports holds the parsed form of the import declarations of a source
+type parsedImports struct {
+	src   []byte
+	fs    *token.FileSet
+	decls []*ast.GenDecl
+}
+
+// Blocks compares the import declarations of the old and the new source and
+// returns the ones that changed. Declarations that were merged into one are
+// reported as a single Block that spans all of them.
+func Blocks(path string, oldSrc, newSrc []byte) ([]Block, error) {
+	oldDecls, err := importDeclarations(path, oldSrc)
+	if err != nil {
+		return nil, err
+	}
+	newDecls, err := importDeclarations(path, newSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	type pair struct {
+		old, new []*ast.GenDecl
+	}
+	pairs := []pair{}
+	if len(oldDecls.decls) == len(newDecls.decls) {
+		for i := range oldDecls.decls {
+			pairs = append(pairs, pair{old: oldDecls.decls[i : i+1], new: newDecls.decls[i : i+1]})
+		}
+	} else if len(oldDecls.decls) != 0 && len(newDecls.decls) != 0 {
+		pairs = append(pairs, pair{old: oldDecls.decls, new: newDecls.decls})
+	}
+
+	blocks := []Block{}
+	for _, p := range pairs {
+		oldStart, oldEnd := oldDecls.span(p.old)
+		newStart, newEnd := newDecls.span(p.new)
+		replacement := newSrc[newStart:newEnd]
+		if bytes.Equal(oldSrc[oldStart:oldEnd], replacement) {
+			continue
+		}
+		tf := oldDecls.fs.File(p.old[0].Pos())
+		blocks = append(blocks, Block{
+			Rule:        classify(oldDecls.groups(p.old), newDecls.groups(p.new)),
+			StartLine:   tf.Line(tf.Pos(oldStart)),
+			EndLine:     tf.Line(tf.Pos(oldEnd)),
+			Offset:      oldStart,
+			Length:      oldEnd - oldStart,
+			Replacement: string(replacement),
+		})
+	}
+	return blocks, nil
+}
+
+// importDeclarations parses the source and returns its import declarations,
+// cgo declarations are never organized so they are left out
+func importDeclarations(path string, src []byte) (*parsedImports, error) {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, path, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse imports of %q: %s", path, err.Error())
+	}
+	d := &parsedImports{src: src, fs: fs}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || isCgoDeclaration(gen) {
+			continue
+		}
+		d.decls = append(d.decls, gen)
+	}
+	return d, nil
+}
+
+// span returns the offsets of the source that hold the declarations
+func (d *parsedImports) span(decls []*ast.GenDecl) (int, int) {
+	tf := d.fs.File(decls[0].Pos())
+	last := decls[len(decls)-1]
+	end := declarationEnd(last)
+	if last.Rparen.IsValid() {
+		end++
+	}
+	return tf.Offset(decls[0].Pos()), tf.Offset(end)
+}
+
+// groups returns the keys of the ImportSpecs of the declarations in the order
+// that they appear, split into groups wherever an empty line or the end of a
+// declaration separates them
+func (d *parsedImports) groups(decls []*ast.GenDecl) [][]string {
+	groups := [][]string{}
+	for _, gen := range decls {
+		groups = append(groups, []string{})
+		prevEnd := token.NoPos
+		for _, s := range gen.Specs {
+			spec := s.(*ast.ImportSpec)
+			start, end := spec.Pos(), spec.End()
+			if spec.Doc != nil {
+				start = spec.Doc.Pos()
+			}
+			if spec.Comment != nil {
+				end = spec.Comment.End()
+			}
+			if prevEnd.IsValid() && d.hasEmptyLine(prevEnd, start) {
+				groups = append(groups, []string{})
+			}
+			key := spec.Path.Value
+			if spec.Name != nil {
+				key = spec.Name.Name + " " + key
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], key)
+			prevEnd = end
+		}
+	}
+	return groups
+}
+
+// hasEmptyLine reports whether there is an empty line between the positions
+func (d *parsedImports) hasEmptyLine(from, to token.Pos) bool {
+	tf := d.fs.File(from)
+	between := d.src[tf.Offset(from):tf.Offset(to)]
+	lines := bytes.Split(between, []byte("\n"))
+	// The first and last line hold the end and start of the ImportSpecs
+	for i := 1; i < len(lines)-1; i++ {
+		if len(bytes.TrimSpace(lines[i])) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// classify returns the Rule that explains the difference between the groups
+// of the old and the organized declaration
+func classify(oldGroups, newGroups [][]string) string {
+	groupOf := map[string]int{}
+	newKeys := []string{}
+	for g, group := range newGroups {
+		for _, key := range group {
+			groupOf[key] = g
+			newKeys = append(newKeys, key)
+		}
+	}
+	oldKeys := []string{}
+	for _, group := range oldGroups {
+		oldKeys = append(oldKeys, group...)
+	}
+	if len(oldKeys) == len(newKeys) {
+		sameOrder := true
+		for i := range oldKeys {
+			if oldKeys[i] != newKeys[i] {
+				sameOrder = false
+				break
+			}
+		}
+		if sameOrder {
+			return RuleMissingSeparator
+		}
+	}
+	// When every import already appears in the order of its group only the
+	// imports within the groups need to be sorted
+	for i := 1; i < len(oldKeys); i++ {
+		if groupOf[oldKeys[i]] < groupOf[oldKeys[i-1]] {
+			return RuleWrongGroup
+		}
+	}
+	return RuleWrongOrder
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package imports
+
+import (
+	"github.com/go-imports-organizer/goio/pkg/constraints"
+)
+
+// SkipConstraints returns the Status and Reason for a file that is skipped
+// because it does not participate in the build according to the constraints,
+// and an empty Status when the file has to be organized. Files whose build
+// constraints can not be parsed are never skipped.
+func SkipConstraints(path string, src []byte, c *constraints.Context) (string, string) {
+	if c == nil {
+		return "", ""
+	}
+	if ok, err := c.Match(path, src); err == nil && !ok {
+		return StatusSkippedConstraints, "excluded by build constraints"
+	}
+	return "", ""
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package imports
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+)
+
+// The directives are line comments, a directive may be followed by a space and
+// an explanation, e.g. "//goio:off init order matters"
+const (
+	// DirectiveIgnore in a comment before the package clause leaves the whole
+	// file untouched
+	DirectiveIgnore string = "goio:ignore"
+	// DirectiveOff in an import declaration pins the ImportSpecs that follow it
+	// in place, up to the next DirectiveOn or the end of the declaration
+	DirectiveOff string = "goio:off"
+	// DirectiveOn ends the ImportSpecs that are pinned by a DirectiveOff
+	DirectiveOn string = "goio:on"
+)
+
+// hasDirective returns true if one of the comments of the group is the directive
+func hasDirective(cg *ast.CommentGroup, directive string) bool {
+	for _, comment := range cg.List {
+		rest, ok := strings.CutPrefix(comment.Text, "//"+directive)
+		if ok && (len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t') {
+			return true
+		}
+	}
+	return false
+}
+
+// HasIgnoreDirective reports whether a //goio:ignore directive precedes the
+// package clause of the file
+func HasIgnoreDirective(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+		if hasDirective(cg, DirectiveIgnore) {
+			return true
+		}
+	}
+	return false
+}
+
+// Skip returns the Status and Reason for a file that is skipped, because of a
+// //goio:ignore directive or according to the generated mode, one of include,
+// skip or only, and an empty Status when the file has to be organized. An empty
+// generated mode skips generated files. Files that can not be parsed are never
+// skipped so that their errors are reported when they are organized.
+func Skip(path string, src []byte, generated string) (string, string) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return "", ""
+	}
+	if HasIgnoreDirective(f) {
+		return StatusSkippedIgnored, "goio:ignore directive"
+	}
+	if generated == v1beta1.GeneratedInclude {
+		return "", ""
+	}
+	isGenerated := ast.IsGenerated(f)
+	switch {
+	case isGenerated && generated != v1beta1.GeneratedOnly:
+		return StatusSkippedGenerated, "generated file"
+	case !isGenerated && generated == v1beta1.GeneratedOnly:
+		return StatusSkippedNotGenerated, "not a generated file"
+	}
+	return "", ""
+}
+
+// pinnedRange is the part of an import declaration from the comment group that
+// holds a //goio:off directive to the end of the comment group that holds the
+// matching //goio:on directive
+type pinnedRange struct {
+	start, end token.Pos
+}
+
+// pinnedRangeOf returns the pinned range that holds the position
+func pinnedRangeOf(pinned []pinnedRange, p token.Pos) (pinnedRange, bool) {
+	for _, r := range pinned {
+		if p >= r.start && p < r.end {
+			return r, true
+		}
+	}
+	return pinnedRange{}, false
+}
+
+// pinnedRanges returns the pinned ranges of a parenthesized import declaration,
+// a //goio:off directive without a matching //goio:on directive pins the rest
+// of the declaration
+func pinnedRanges(f *ast.File, gen *ast.GenDecl) []pinnedRange {
+	if !gen.Lparen.IsValid() {
+		return nil
+	}
+	pinned := []pinnedRange{}
+	start := token.NoPos
+	end := token.NoPos
+	for _, cg := range f.Comments {
+		if cg.Pos() <= gen.Lparen || cg.Pos() >= gen.Rparen {
+			continue
+		}
+		end = max(end, cg.End())
+		switch {
+		case !start.IsValid() && hasDirective(cg, DirectiveOff):
+			start = cg.Pos()
+		case start.IsValid() && hasDirective(cg, DirectiveOn):
+			pinned = append(pinned, pinnedRange{start: start, end: cg.End()})
+			start = token.NoPos
+		}
+	}
+	if start.IsValid() {
+		for _, spec := range gen.Specs {
+			end = max(end, spec.End())
+		}
+		pinned = append(pinned, pinnedRange{start: start, end: end})
+	}
+	return pinned
+}
+
+// insertPinnedGroups organizes the ImportSpecs of an import declaration that
+// holds pinned ranges. The pinned ImportSpecs keep their place and their layout,
+// the ImportSpecs before, between and after the pinned ranges are organized
+// into groups on their own so that no ImportSpec moves across a pinned range.
+func insertPinnedGroups(fs *token.FileSet, f *ast.File, gen *ast.GenDecl, pinned []pinnedRange, regExpMatchers []v1alpha1.RegExpMatcher, displayOrder []string, sorts map[string]v1alpha1.Sort) error {
+	specs := []*ast.ImportSpec{}
+	breaks := map[*ast.ImportSpec]bool{}
+	section := []*ast.ImportSpec{}
+	insertSection := func() error {
+		importGroups := map[string][]ast.ImportSpec{}
+		if err := PopulateGroups(importGroups, regExpMatchers, section); err != nil {
+			return err
+		}
+		for n, spec := range orderGroups(importGroups, displayOrder, sorts, breaks) {
+			if n == 0 && len(specs) != 0 {
+				breaks[spec] = true
+			}
+			specs = append(specs, spec)
+		}
+		section = []*ast.ImportSpec{}
+		return nil
+	}
+
+	lastPinned := false
+	for _, spec := range gen.Specs {
+		spec := spec.(*ast.ImportSpec)
+		if _, ok := pinnedRangeOf(pinned, spec.Pos()); !ok {
+			section = append(section, spec)
+			lastPinned = false
+			continue
+		}
+		if err := insertSection(); err != nil {
+			return err
+		}
+		if len(specs) != 0 && !lastPinned {
+			breaks[spec] = true
+		}
+		specs = append(specs, spec)
+		lastPinned = true
+	}
+	if err := insertSection(); err != nil {
+		return err
+	}
+
+	gen.Specs = []ast.Spec{}
+	for _, spec := range specs {
+		gen.Specs = append(gen.Specs, spec)
+	}
+	return layoutImports(fs, f, gen, specs, breaks, pinned)
+}
@@ -16,71 +16,31 @@ limitations under the License.
 package imports
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"go/ast"
-	"go/format"
 	"go/parser"
 	"go/printer"
 	"go/scanner"
 	"go/token"
-	"io"
 	"os"
-	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	"github.com/go-imports-organizer/goio/pkg/constraints"
+	"github.com/go-imports-organizer/goio/pkg/diff"
 	"github.com/go-imports-organizer/goio/pkg/sorter"
 )
 
-// AddSpaces adds empty lines (spaces) between the groups of imports
-// borrowed from https://github.com/golang/tools/blob/71482053b885ea3938876d1306ad8a1e4037f367/internal/imports/imports.go#L380
-func AddSpaces(r io.Reader, breaks []string) ([]byte, error) {
-	var out bytes.Buffer
-	in := bufio.NewReader(r)
-	inImports := false
-	done := false
-	for {
-		s, err := in.ReadString('\n')
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, err
-		}
-
-		if !inImports && !done && strings.HasPrefix(s, "import") {
-			inImports = true
-		}
-		if inImports && (strings.HasPrefix(s, "var") ||
-			strings.HasPrefix(s, "func") ||
-			strings.HasPrefix(s, "const") ||
-			strings.HasPrefix(s, "type")) {
-			done = true
-			inImports = false
-		}
-		if inImports && len(breaks) > 0 {
-			if m := regexp.MustCompile(`^\s+(?:[\w\.]+\s+)?"(.+)"`).FindStringSubmatch(s); m != nil {
-				if m[1] == breaks[0] {
-					out.WriteByte('\n')
-					breaks = breaks[1:]
-				}
-			}
-		}
-		fmt.Fprint(&out, s)
-	}
-	return out.Bytes(), nil
-}
-
 // PopulateGroups assembles the data structure that is used to hold the groups
-// of ImportSpecs as they are organized
+// of ImportSpecs as they are organized. The cgo pseudo-import "C" is never
+// added to a group, it has to stay in place directly after its preamble.
 func PopulateGroups(importGroups map[string][]ast.ImportSpec, regExpMatchers []v1alpha1.RegExpMatcher, imports []*ast.ImportSpec) error {
 	for _, i := range imports {
-		if len(i.Path.Value) == 0 {
+		if len(i.Path.Value) == 0 || i.Path.Value == cgoImportPath {
 			continue
 		}
 		found := false
@@ -105,121 +65,402 @@ func PopulateGroups(importGroups map[string][]ast.ImportSpec, regExpMatchers []v
 	return nil
 }
 
-// InsertGroup places the groups of ImportSpecs into their correct order in the
-// File according to the display order
-func InsertGroups(f *ast.File, importGroups map[string][]ast.ImportSpec, displayOrder []string) ([]string, error) {
-	var breaks []string
+// MergeImportDeclarations merges consecutive import declarations into the first
+// one of them so that they can be organized as a single block. Declarations that
+// import "C" are never merged, cgo requires them to directly follow their
+// preamble, so they also separate the declarations before and after them.
+func MergeImportDeclarations(f *ast.File) {
+	decls := []ast.Decl{}
+	var target *ast.GenDecl
 	for _, decl := range f.Decls {
 		gen, ok := decl.(*ast.GenDecl)
-		if ok && gen.Tok == token.IMPORT {
-			gen.Specs = []ast.Spec{}
-			for _, group := range displayOrder {
-				sort.Sort(sorter.SortImportsByPathValue(importGroups[group]))
-				for n := range importGroups[group] {
-					importGroups[group][n].EndPos = 0
-					importGroups[group][n].Path.ValuePos = 0
-					if importGroups[group][n].Name != nil {
-						importGroups[group][n].Name.NamePos = 0
-					}
-					gen.Specs = append(gen.Specs, &importGroups[group][n])
-					if n == 0 && group != displayOrder[0] {
-						newstr, err := strconv.Unquote(importGroups[group][n].Path.Value)
-						if err != nil {
-							return nil, err
-						}
-						breaks = append(breaks, newstr)
-					}
+		if !ok || gen.Tok != token.IMPORT || isCgoDeclaration(gen) {
+			target = nil
+			decls = append(decls, decl)
+			continue
+		}
+		if target == nil {
+			target = gen
+			decls = append(decls, decl)
+			continue
+		}
+		// The merged declaration spans from the first declaration to the end of
+		// the last one, comments in between end up inside of the new block
+		if !target.Lparen.IsValid() {
+			target.Lparen = target.Specs[0].Pos() - 1
+		}
+		target.Specs = append(target.Specs, gen.Specs...)
+		target.Rparen = declarationEnd(gen)
+	}
+	f.Decls = decls
+}
+
+// cgoImportPath is the quoted path of the cgo pseudo-import
+const cgoImportPath = `"C"`
+
+// isCgoDeclaration returns true if the import declaration imports "C", these
+// declarations are left untouched together with their preamble comment
+func isCgoDeclaration(gen *ast.GenDecl) bool {
+	for _, spec := range gen.Specs {
+		if i, ok := spec.(*ast.ImportSpec); ok && i.Path.Value == cgoImportPath {
+			return true
+		}
+	}
+	return false
+}
+
+// declarationEnd returns the position of the closing parenthesis of an import
+// declaration, or the end of its only ImportSpec including its line comment
+func declarationEnd(gen *ast.GenDecl) token.Pos {
+	if gen.Rparen.IsValid() || len(gen.Specs) == 0 {
+		return gen.Rparen
+	}
+	spec := gen.Specs[len(gen.Specs)-1].(*ast.ImportSpec)
+	if spec.Comment != nil {
+		return spec.Comment.End()
+	}
+	return spec.End()
+}
+
+// InsertGroup places the groups of ImportSpecs into their correct order in the
+// import declaration according to the display order, separated by empty lines.
+// The ImportSpecs within each group are sorted according to the groups Sort.
+// Comments that are attached to an ImportSpec are moved along with it.
+func InsertGroups(fs *token.FileSet, f *ast.File, gen *ast.GenDecl, importGroups map[string][]ast.ImportSpec, displayOrder []string, sorts map[string]v1alpha1.Sort) error {
+	breaks := map[*ast.ImportSpec]bool{}
+	specs := orderGroups(importGroups, displayOrder, sorts, breaks)
+	gen.Specs = []ast.Spec{}
+	for _, spec := range specs {
+		gen.Specs = append(gen.Specs, spec)
+	}
+	if !gen.Lparen.IsValid() {
+		// A declaration without parenthesis only holds a single ImportSpec
+		return nil
+	}
+	return layoutImports(fs, f, gen, specs, breaks, nil)
+}
+
+// orderGroups returns the ImportSpecs of the groups in the display order, sorted
+// according to the groups Sort. The first ImportSpec of every group but the
+// first one is added to breaks.
+func orderGroups(importGroups map[string][]ast.ImportSpec, displayOrder []string, sorts map[string]v1alpha1.Sort, breaks map[*ast.ImportSpec]bool) []*ast.ImportSpec {
+	specs := []*ast.ImportSpec{}
+	for _, group := range displayOrder {
+		for _, block := range sorter.SortImports(importGroups[group], sorts[group]) {
+			for n := range block {
+				if n == 0 && len(specs) != 0 {
+					breaks[&block[n]] = true
 				}
+				specs = append(specs, &block[n])
 			}
 		}
 	}
-	return breaks, nil
+	return specs
+}
+
+// File is a Go file that has been queued for organizing, the Index is used to
+// report the Results in the same order that the Files were queued
+type File struct {
+	// Index is the position of the File in the queue
+	Index int
+	// Path is the path to the Go file
+	Path string
+	// Rules are used to organize the File instead of the ones that were passed
+	// to Format when set
+	Rules *Rules
+	// Constraints decide whether the File participates in the build, files
+	// that do not are skipped. Every File participates when it is nil.
+	Constraints *constraints.Context
 }
 
-// Format processes files as they are added to the queue and organizes the imports
-func Format(files *chan string, resultsChan *chan string, hasResults *bool, wg *sync.WaitGroup, groupRegExpMatchers []v1alpha1.RegExpMatcher, displayOrder []string, listOnly *bool) {
+// Rules are the settings that are used to organize the imports of a File
+type Rules struct {
+	// GroupRegExpMatchers are the groups in the order that they are matched in
+	GroupRegExpMatchers []v1alpha1.RegExpMatcher
+	// DisplayOrder is the order that the groups are displayed in
+	DisplayOrder []string
+	// KeepImportDeclarations keeps multiple import declarations apart
+	KeepImportDeclarations bool
+	// Generated is one of include, skip or only and decides whether generated
+	// files are organized, generated files are skipped when it is empty
+	Generated string
+}
+
+const (
+	// StatusUnchanged is used for files whose imports were already organized
+	StatusUnchanged string = "unchanged"
+	// StatusChanged is used for files whose imports were not organized
+	StatusChanged string = "changed"
+	// StatusError is used for files that could not be organized
+	StatusError string = "error"
+	// StatusSkippedModified is used for files that were modified by someone
+	// else while they were being organized
+	StatusSkippedModified string = "skipped-modified"
+	// StatusSkippedGenerated is used for generated files when they are skipped
+	StatusSkippedGenerated string = "skipped-generated"
+	// StatusSkippedNotGenerated is used for files that are not generated when
+	// only generated files are organized
+	StatusSkippedNotGenerated string = "skipped-not-generated"
+	// StatusSkippedIgnored is used for files with a //goio:ignore directive
+	StatusSkippedIgnored string = "skipped-ignored"
+	// StatusSkippedConstraints is used for files that are excluded by the
+	// build constraints
+	StatusSkippedConstraints string = "skipped-constraints"
+)
+
+// Result is the outcome of organizing a single File
+type Result struct {
+	// Index is the position of the File in the queue
+	Index int
+	// Path is the path to the Go file
+	Path string
+	// Status is the outcome of organizing the file, one of the Status constants
+	Status string
+	// Reason explains why the file was skipped
+	Reason string
+	// Moved lists the imports that had to be moved to organize the file
+	Moved []MovedImport
+	// Blocks lists the import declarations that are not organized
+	Blocks []Block
+	// Diff is the unified diff of the changes, it is only populated in diff mode
+	Diff []byte
+	// Err is set when the file could not be organized
+	Err error
+}
+
+// Format processes files as they are added to the queue and organizes the imports.
+// Exactly one Result is sent to the resultsChan for every File that is queued.
+// When diffOnly is set a unified diff of the changes is included in the Result
+// and the file is left untouched. Multiple import declarations in a file are
+// merged into one unless keepImportDeclarations is set. Generated files, files
+// with a //goio:ignore directive and files that are excluded by the Constraints
+// of their File are skipped. Files that carry their own Rules are organized
+// according to those instead.
+func Format(files *chan File, resultsChan *chan Result, wg *sync.WaitGroup, groupRegExpMatchers []v1alpha1.RegExpMatcher, displayOrder []string, keepImportDeclarations bool, listOnly *bool, diffOnly *bool) {
 	defer wg.Done()
-	for path := range *files {
-		if len(path) == 0 {
-			continue
+	for file := range *files {
+		rules := Rules{GroupRegExpMatchers: groupRegExpMatchers, DisplayOrder: displayOrder, KeepImportDeclarations: keepImportDeclarations}
+		if file.Rules != nil {
+			rules = *file.Rules
 		}
+		result := formatFile(file.Path, rules, file.Constraints, *listOnly, *diffOnly)
+		result.Index = file.Index
+		*resultsChan <- result
+	}
+}
 
-		info, err := os.Stat(path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to stat %q", err.Error())
-			continue
+// OrderResults reads Results from the resultsChan until it is closed and calls
+// report for each of them in the order that their Files were queued
+func OrderResults(resultsChan *chan Result, report func(Result)) {
+	pending := map[int]Result{}
+	next := 0
+	for r := range *resultsChan {
+		pending[r.Index] = r
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			report(r)
+		}
+	}
+}
+
+// formatFile organizes the imports of a single file that participates in the
+// build according to the constraints
+func formatFile(path string, rules Rules, c *constraints.Context, listOnly bool, diffOnly bool) Result {
+	result := Result{Path: path, Status: StatusUnchanged}
+	if len(path) == 0 {
+		return result
+	}
+	fail := func(err error) Result {
+		result.Status = StatusError
+		result.Err = err
+		return result
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fail(fmt.Errorf("unable to stat %q: %s", path, err.Error()))
+	}
+	oldModTime := info.ModTime()
+
+	oldFile, err := os.ReadFile(path)
+	if err != nil {
+		return fail(fmt.Errorf("unable to read file %q: %s", path, err.Error()))
+	}
+
+	if status, reason := SkipConstraints(path, oldFile, c); len(status) != 0 {
+		result.Status = status
+		result.Reason = reason
+		return result
+	}
+
+	if status, reason := Skip(path, oldFile, rules.Generated); len(status) != 0 {
+		result.Status = status
+		result.Reason = reason
+		return result
+	}
+
+	out, err := Organize(path, oldFile, rules.GroupRegExpMatchers, rules.DisplayOrder, rules.KeepImportDeclarations)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !bytes.Equal(oldFile, out) {
+		result.Status = StatusChanged
+		if result.Moved, err = MovedImports(path, oldFile, out); err != nil {
+			return fail(err)
+		}
+		if result.Blocks, err = Blocks(path, oldFile, out); err != nil {
+			return fail(err)
 		}
-		oldModTime := info.ModTime()
+		if diffOnly {
+			result.Diff = diff.Unified(path, oldFile, out)
+		}
+	}
 
-		fs := token.NewFileSet()
-		f, err := parser.ParseFile(fs, path, nil, parser.ParseComments)
+	if !listOnly && !diffOnly {
+		info, err = os.Stat(path)
 		if err != nil {
-			var scannerErrorList scanner.ErrorList
-			if errors.As(err, &scannerErrorList) {
-				for _, err := range scannerErrorList {
-					fmt.Fprintf(os.Stderr, "%s", err)
-				}
-			} else {
-				fmt.Fprintf(os.Stderr, "%s", err.Error())
-			}
+			return fail(fmt.Errorf("unable to stat %q: %s", path, err.Error()))
+		}
+		if !info.ModTime().Equal(oldModTime) {
+			result.Status = StatusSkippedModified
+			result.Reason = "file was modified while organizing, cowardly refusing to overwrite"
+			return result
 		}
+		if err = os.WriteFile(path, out, info.Mode()); err != nil {
+			return fail(fmt.Errorf("unable to write to path %q, %s", path, err.Error()))
+		}
+	}
+	return result
+}
 
-		var importGroups = make(map[string][]ast.ImportSpec)
-		if err := PopulateGroups(importGroups, groupRegExpMatchers, f.Imports); err != nil {
-			fmt.Fprintf(os.Stderr, "unable to populate import groups for %q: %s", path, err)
-			continue
+// Organize returns the source with its imports organized, the path is only used
+// for error messages. The source is not required to be formatted with gofmt but
+// the result always is, except that the imports are not sorted by gofmt.
+func Organize(path string, src []byte, groupRegExpMatchers []v1alpha1.RegExpMatcher, displayOrder []string, keepImportDeclarations bool) ([]byte, error) {
+	out, err := organize(path, src, groupRegExpMatchers, displayOrder, keepImportDeclarations)
+	if errors.Is(err, errImportBlockTooSmall) {
+		// The import block is too compact to hold the organized imports, this
+		// is fixed by formatting the source first
+		var formatted []byte
+		if formatted, err = formatSource(path, src); err != nil {
+			return nil, err
+		}
+		out, err = organize(path, formatted, groupRegExpMatchers, displayOrder, keepImportDeclarations)
+		if errors.Is(err, errImportBlockTooSmall) {
+			return nil, fmt.Errorf("unable to update groups at %q: %s", path, err.Error())
 		}
+	}
+	return out, err
+}
 
-		breaks, err := InsertGroups(f, importGroups, displayOrder)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to update groups at %q: %s", path, err.Error())
-			continue
+// organize returns the source with its imports organized
+func organize(path string, src []byte, groupRegExpMatchers []v1alpha1.RegExpMatcher, displayOrder []string, keepImportDeclarations bool) ([]byte, error) {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, path, src, parser.ParseComments)
+	if err != nil {
+		var scannerErrorList scanner.ErrorList
+		if errors.As(err, &scannerErrorList) {
+			messages := []string{}
+			for _, err := range scannerErrorList {
+				messages = append(messages, err.Error())
+			}
+			return nil, errors.New(strings.Join(messages, "\n"))
 		}
+		return nil, err
+	}
 
-		printerMode := printer.TabIndent
+	if !keepImportDeclarations {
+		MergeImportDeclarations(f)
+	}
 
-		printConfig := &printer.Config{Mode: printerMode, Tabwidth: 4}
+	sorts := map[string]v1alpha1.Sort{}
+	for _, r := range groupRegExpMatchers {
+		sorts[r.Bucket] = r.Sort
+	}
 
-		var buf bytes.Buffer
-		if err = printConfig.Fprint(&buf, fs, f); err != nil {
-			fmt.Fprintf(os.Stderr, "unable to load bytes into buffer %q, %s", path, err.Error())
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || isCgoDeclaration(gen) {
 			continue
 		}
-		out, err := AddSpaces(bytes.NewReader(buf.Bytes()), breaks)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to add spaces to %q, %s", path, err.Error())
+		// The ImportSpecs between //goio:off and //goio:on directives are
+		// pinned, they are detected before the groups are populated
+		if pinned := pinnedRanges(f, gen); len(pinned) != 0 {
+			if err := insertPinnedGroups(fs, f, gen, pinned, groupRegExpMatchers, displayOrder, sorts); err != nil {
+				if errors.Is(err, errImportBlockTooSmall) {
+					return nil, err
+				}
+				return nil, fmt.Errorf("unable to update groups at %q: %s", path, err.Error())
+			}
 			continue
 		}
-		out, err = format.Source(out)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to format source %q, %s", path, err.Error())
-		}
 
-		oldFile, err := os.ReadFile(path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to read file %q: %s", path, err.Error())
-			continue
+		specs := []*ast.ImportSpec{}
+		for _, spec := range gen.Specs {
+			specs = append(specs, spec.(*ast.ImportSpec))
 		}
-		if !bytes.Equal(oldFile, out) {
-			*hasResults = true
-			*resultsChan <- path
+
+		var importGroups = make(map[string][]ast.ImportSpec)
+		if err := PopulateGroups(importGroups, groupRegExpMatchers, specs); err != nil {
+			return nil, fmt.Errorf("unable to populate import groups for %q: %s", path, err)
 		}
 
-		if !*listOnly {
-			info, err = os.Stat(path)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "unable to stat %q: %s", path, err.Error())
-				continue
-			}
-			if !info.ModTime().Equal(oldModTime) {
-				fmt.Fprintf(os.Stderr, "%s was modified while formatting, cowardly refusing to overwrite", path)
-				continue
-			}
-			if err = os.WriteFile(path, out, info.Mode()); err != nil {
-				fmt.Fprintf(os.Stderr, "unable to write to path %q, %s", path, err.Error())
-				continue
+		if err := InsertGroups(fs, f, gen, importGroups, displayOrder, sorts); err != nil {
+			if errors.Is(err, errImportBlockTooSmall) {
+				return nil, err
 			}
+			return nil, fmt.Errorf("unable to update groups at %q: %s", path, err.Error())
 		}
+	}
+
+	return printFile(path, fs, f)
+}
+
+// printerNormalizeNumbers is the printer mode that go/format and gofmt use to
+// normalize number literals, such as 0X1F to 0x1F, it is not exported by
+// go/printer
+const printerNormalizeNumbers printer.Mode = 1 << 30
+
+// gofmtConfig holds the printer settings of gofmt. The file is printed with
+// them directly instead of with format.Source, which would sort every run of
+// imports again and undo the Sort of the groups and the pinned imports.
+var gofmtConfig = &printer.Config{Mode: printer.UseSpaces | printer.TabIndent | printerNormalizeNumbers, Tabwidth: 8}
+
+// printFile returns the file formatted like gofmt does, without sorting the
+// imports. Like ast.SortImports does for gofmt the empty lines before the
+// closing parenthesis of an import declaration are removed.
+func printFile(path string, fs *token.FileSet, f *ast.File) ([]byte, error) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Rparen.IsValid() || len(gen.Specs) == 0 {
+			continue
+		}
+		tf := fs.File(gen.Rparen)
+		last := tf.Line(gen.Specs[len(gen.Specs)-1].Pos())
+		for line := tf.Line(gen.Rparen); line > last+1; line-- {
+			tf.MergeLine(line - 1)
+		}
+	}
+	var buf bytes.Buffer
+	if err := gofmtConfig.Fprint(&buf, fs, f); err != nil {
+		return nil, fmt.Errorf("unable to load bytes into buffer %q, %s", path, err.Error())
+	}
+	return buf.Bytes(), nil
+}
 
+// formatSource returns the source formatted like gofmt does, without sorting
+// the imports
+func formatSource(path string, src []byte) ([]byte, error) {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("unable to format source %q, %s", path, err.Error())
 	}
+	return printFile(path, fs, f)
 }
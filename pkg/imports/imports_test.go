@@ -17,242 +17,747 @@ package imports
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
-	"reflect"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
+	v1beta1 "github.com/go-imports-organizer/goio/pkg/api/v1beta1"
+	"github.com/go-imports-organizer/goio/pkg/constraints"
 	"github.com/go-imports-organizer/goio/pkg/groups"
+	"github.com/go-imports-organizer/goio/pkg/module"
 )
 
-func TestAddSpaces(t *testing.T) {
+func TestFormat(t *testing.T) {
+	organized := `package example
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/example/module/pkg/one"
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	unorganized := `package example
+
+import (
+	"github.com/example/module/pkg/one"
+	"fmt"
+	"os"
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	commented := `package example
+
+import (
+	// one is documented
+	"github.com/example/module/pkg/one" // one
+	_ "embed" // for templates
+	//nolint:depguard
+	"fmt"
+	"os" /* os */
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	commentedOrganized := `package example
+
+import (
+	_ "embed" // for templates
+	//nolint:depguard
+	"fmt"
+	"os" /* os */
+
+	// one is documented
+	"github.com/example/module/pkg/one" // one
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	compact := `package example
+
+import ("github.com/example/module/pkg/one"; "fmt"; "os" // os
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	compactOrganized := `package example
+
+import (
+	"fmt"
+	"os" // os
+
+	"github.com/example/module/pkg/one"
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	multiple := `package example
+
+import "github.com/example/module/pkg/one"
+import (
+	"fmt"
+)
+
+// os is documented
+import "os" // os
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	multipleMerged := `package example
+
+import (
+	"fmt"
+	// os is documented
+	"os" // os
+
+	"github.com/example/module/pkg/one"
+)
+
+var _, _, _ = fmt.Print, os.Exit, one.Name
+`
+	multipleKept := `package example
+
+import "github.com/example/module/pkg/one"
+import (
+	"fmt"
+	"github.com/example/module/pkg/two"
+)
+
+var _, _, _ = fmt.Print, two.Name, one.Name
+`
+	multipleKeptOrganized := `package example
+
+import "github.com/example/module/pkg/one"
+import (
+	"fmt"
+
+	"github.com/example/module/pkg/two"
+)
+
+var _, _, _ = fmt.Print, two.Name, one.Name
+`
+	nested := `package example
+
+import (
+	"github.com/example/module/pkg/one"
+	"github.com/example/nested/pkg/two"
+	"fmt"
+)
+
+var _, _, _ = fmt.Print, two.Name, one.Name
+`
+	nestedOrganized := `package example
+
+import (
+	"fmt"
+
+	"github.com/example/module/pkg/one"
+
+	"github.com/example/nested/pkg/two"
+)
+
+var _, _, _ = fmt.Print, two.Name, one.Name
+`
+	nestedRootOrganized := `package example
+
+import (
+	"fmt"
+
+	"github.com/example/nested/pkg/two"
+
+	"github.com/example/module/pkg/one"
+)
+
+var _, _, _ = fmt.Print, two.Name, one.Name
+`
+	generated := "// Code generated by generator. DO NOT EDIT.\n\n" + unorganized
+	defaultGroups := []v1beta1.Group{
+		{
+			Name:   "standard",
+			RegExp: []string{`^[a-zA-Z0-9\/]+$`},
+		},
+		{
+			Name:   "other",
+			RegExp: []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+		},
+		{
+			Name:   "module",
+			RegExp: []string{"%{module}%"},
+		},
+	}
 	type args struct {
-		input  []byte
-		breaks []string
+		sources                []string
+		workers                int
+		keepImportDeclarations bool
+		listOnly               bool
+		diffOnly               bool
+		nestedRules            []bool
+		generated              string
+		constraints            *constraints.Context
 	}
 	tests := []struct {
-		name string
-		args args
-		want []byte
+		name        string
+		args        args
+		wantChanged []bool
+		wantSkipped []bool
+		wantSources []string
 	}{
 		{
-			name: "works with function",
+			name: "organizes files",
 			args: args{
-				input: []byte(`imports (
-	"fmt",
-	"io",
-	"reflect",
-	"sort",
-)
-
-func main() {}
-`),
-				breaks: []string{
-					"io",
-					"sort",
-				},
+				sources: []string{unorganized, organized},
+				workers: 1,
 			},
-			want: []byte(`imports (
-	"fmt",
+			wantChanged: []bool{true, false},
+			wantSources: []string{organized, organized},
+		},
+		{
+			name: "comments move with their imports",
+			args: args{
+				sources: []string{commented, compact},
+				workers: 1,
+			},
+			wantChanged: []bool{true, true},
+			wantSources: []string{commentedOrganized, compactOrganized},
+		},
+		{
+			name: "multiple import declarations are merged",
+			args: args{
+				sources: []string{multiple},
+				workers: 1,
+			},
+			wantChanged: []bool{true},
+			wantSources: []string{multipleMerged},
+		},
+		{
+			name: "multiple import declarations are kept",
+			args: args{
+				sources:                []string{multipleKept},
+				workers:                1,
+				keepImportDeclarations: true,
+			},
+			wantChanged: []bool{true},
+			wantSources: []string{multipleKeptOrganized},
+		},
+		{
+			name: "list only leaves files untouched",
+			args: args{
+				sources:  []string{unorganized, organized},
+				workers:  1,
+				listOnly: true,
+			},
+			wantChanged: []bool{true, false},
+			wantSources: []string{unorganized, organized},
+		},
+		{
+			name: "diff only leaves files untouched",
+			args: args{
+				sources:  []string{organized, unorganized},
+				workers:  1,
+				diffOnly: true,
+			},
+			wantChanged: []bool{false, true},
+			wantSources: []string{organized, unorganized},
+		},
+		{
+			name: "results are reported in queue order with multiple workers",
+			args: args{
+				sources:  []string{unorganized, organized, organized, unorganized, organized, unorganized, unorganized, organized},
+				workers:  4,
+				listOnly: true,
+			},
+			wantChanged: []bool{true, false, false, true, false, true, true, false},
+			wantSources: []string{unorganized, organized, organized, unorganized, organized, unorganized, unorganized, organized},
+		},
+		{
+			name: "generated files are skipped",
+			args: args{
+				sources: []string{generated, unorganized},
+				workers: 1,
+			},
+			wantChanged: []bool{false, true},
+			wantSkipped: []bool{true, false},
+			wantSources: []string{generated, organized},
+		},
+		{
+			name: "generated files are included",
+			args: args{
+				sources:   []string{generated, unorganized},
+				workers:   1,
+				generated: v1beta1.GeneratedInclude,
+			},
+			wantChanged: []bool{true, true},
+			wantSkipped: []bool{false, false},
+			wantSources: []string{"// Code generated by generator. DO NOT EDIT.\n\n" + organized, organized},
+		},
+		{
+			name: "only generated files are organized",
+			args: args{
+				sources:   []string{generated, unorganized},
+				workers:   1,
+				generated: v1beta1.GeneratedOnly,
+			},
+			wantChanged: []bool{true, false},
+			wantSkipped: []bool{false, true},
+			wantSources: []string{"// Code generated by generator. DO NOT EDIT.\n\n" + organized, unorganized},
+		},
+		{
+			name: "files that are excluded by build constraints are skipped",
+			args: args{
+				sources:     []string{"//go:build windows\n\n" + unorganized, unorganized},
+				workers:     1,
+				constraints: constraints.New("linux", "amd64", nil),
+			},
+			wantChanged: []bool{false, true},
+			wantSkipped: []bool{true, false},
+			wantSources: []string{"//go:build windows\n\n" + unorganized, organized},
+		},
+		{
+			name: "files are organized with their own rules",
+			args: args{
+				sources:     []string{nested, nested},
+				workers:     2,
+				nestedRules: []bool{true, false},
+			},
+			wantChanged: []bool{true, true},
+			wantSources: []string{nestedOrganized, nestedRootOrganized},
+		},
+	}
+	regExpMatchers, displayOrder, err := groups.Build(defaultGroups, []string{"module", "standard", "other"}, module.Module{Name: "github.com/example/module"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
+	}
+	nestedRegExpMatchers, nestedDisplayOrder, err := groups.Build(defaultGroups, []string{"module", "standard", "other"}, module.Module{Name: "github.com/example/nested"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
+	}
+	nestedRules := &Rules{GroupRegExpMatchers: nestedRegExpMatchers, DisplayOrder: nestedDisplayOrder}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
 
-	"io",
-	"reflect",
+			wg := sync.WaitGroup{}
+			files := make(chan File)
+			resultsChan := make(chan Result)
+			wg.Add(tt.args.workers)
+			for i := 0; i < tt.args.workers; i++ {
+				go Format(&files, &resultsChan, &wg, regExpMatchers, displayOrder, tt.args.keepImportDeclarations, &tt.args.listOnly, &tt.args.diffOnly)
+			}
 
-	"sort",
-)
+			got := []Result{}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				OrderResults(&resultsChan, func(r Result) {
+					got = append(got, r)
+				})
+			}()
+
+			paths := []string{}
+			for i, source := range tt.args.sources {
+				path := filepath.Join(dir, fmt.Sprintf("file%d.go", i))
+				if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+					t.Fatalf("unable to write %s: %s", path, err.Error())
+				}
+				paths = append(paths, path)
+				file := File{Index: i, Path: path, Constraints: tt.args.constraints}
+				if i < len(tt.args.nestedRules) && tt.args.nestedRules[i] {
+					file.Rules = nestedRules
+				}
+				if len(tt.args.generated) != 0 {
+					file.Rules = &Rules{GroupRegExpMatchers: regExpMatchers, DisplayOrder: displayOrder, Generated: tt.args.generated}
+				}
+				files <- file
+			}
+			close(files)
+			wg.Wait()
+			close(resultsChan)
+			<-done
 
-func main() {}
-`),
+			if len(got) != len(tt.wantChanged) {
+				t.Fatalf("Format() returned %d results, want %d", len(got), len(tt.wantChanged))
+			}
+			for i, r := range got {
+				if r.Err != nil {
+					t.Errorf("Format() error = %v", r.Err)
+				}
+				if r.Path != paths[i] {
+					t.Errorf("Format() result %d path = %s, want %s", i, r.Path, paths[i])
+				}
+				changed := r.Status == StatusChanged
+				if changed != tt.wantChanged[i] {
+					t.Errorf("Format() result %d status = %s, want changed %v", i, r.Status, tt.wantChanged[i])
+				}
+				skipped := r.Status == StatusSkippedGenerated || r.Status == StatusSkippedNotGenerated || r.Status == StatusSkippedConstraints
+				if i < len(tt.wantSkipped) && skipped != tt.wantSkipped[i] {
+					t.Errorf("Format() result %d status = %s, want skipped %v", i, r.Status, tt.wantSkipped[i])
+				}
+				if tt.args.diffOnly && changed && len(r.Diff) == 0 {
+					t.Errorf("Format() result %d is missing its diff", i)
+				}
+				content, err := os.ReadFile(paths[i])
+				if err != nil {
+					t.Fatalf("unable to read %s: %s", paths[i], err.Error())
+				}
+				if string(content) != tt.wantSources[i] {
+					t.Errorf("Format() file %d = %s, want %s", i, content, tt.wantSources[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOrganize(t *testing.T) {
+	organizeGroups := []v1beta1.Group{
+		{
+			Name:   "standard",
+			RegExp: []string{`^[a-zA-Z0-9\/]+$`},
 		},
 		{
-			name: "standard imports",
+			Name:   "module",
+			RegExp: []string{"%{module}%"},
+		},
+	}
+	type args struct {
+		src string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantErr    bool
+		wantErrMsg string
+	}{
+		{
+			name: "organizes source",
 			args: args{
-				input: []byte(`imports (
-	"fmt",
-	"io",
-	"reflect",
-	"sort",
-)
-`),
-				breaks: []string{
-					"io",
-					"sort",
-				},
+				src: "package example\n\nimport (\n\t\"fmt\"\n\t\"github.com/example/module/pkg/one\"\n\t\"os\"\n)\n",
 			},
-			want: []byte(`imports (
-	"fmt",
-
-	"io",
-	"reflect",
-
-	"sort",
-)
-`),
+			want: "package example\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n",
 		},
 		{
-			name: "kubernetes imports",
+			name: "source that is not formatted",
 			args: args{
-				input: []byte(`imports (
-	"k8s.io/api/core/v1alpha1",
-	"k8s.io/apimachinery/pkg/api/errors",
-	"k8s.io/apimachinery/pkg/apis/meta/v1alpha1",
-	"k8s.io/apimachinery/pkg/util/sets",
-	"k8s.io/apimachinery/pkg/util/uuid",
-	"k8s.io/apimachinery/pkg/util/wait",
-)
-`),
-				breaks: []string{
-					"k8s.io/apimachinery/pkg/apis/meta/v1alpha1",
-					"k8s.io/apimachinery/pkg/util/uuid",
-				},
+				src: "package example\nimport (\"github.com/example/module/pkg/one\";\"fmt\")\nfunc  main()  {}",
 			},
-			want: []byte(`imports (
-	"k8s.io/api/core/v1alpha1",
-	"k8s.io/apimachinery/pkg/api/errors",
-
-	"k8s.io/apimachinery/pkg/apis/meta/v1alpha1",
-	"k8s.io/apimachinery/pkg/util/sets",
-
-	"k8s.io/apimachinery/pkg/util/uuid",
-	"k8s.io/apimachinery/pkg/util/wait",
-)
-`),
+			want: "package example\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n\nfunc main() {}\n",
 		},
 		{
-			name: "openshift imports",
+			name: "number literals are normalized like gofmt does",
 			args: args{
-				input: []byte(`imports (
-	"github.com/openshift/api/build/v1alpha1",
-	"github.com/openshift/client-go/build/clientset/versioned/typed/build/v1alpha1",
-	"github.com/openshift/imagebuilder",
-	"github.com/openshift/imagebuilder/dockerfile/command",
-	"github.com/openshift/imagebuilder/dockerfile/parser",
-	"github.com/openshift/library-go/pkg/git",
-	"github.com/openshift/library-go/pkg/image/reference",
-	"github.com/openshift/source-to-image/pkg/scm/git",
-	"github.com/openshift/source-to-image/pkg/util",
-)
-`),
-				breaks: []string{
-					"github.com/openshift/imagebuilder/dockerfile/command",
-					"github.com/openshift/library-go/pkg/git",
-					"github.com/openshift/source-to-image/pkg/util",
-				},
+				src: "package example\nimport (\"github.com/example/module/pkg/one\";\"fmt\")\nconst x = 0X1F\n",
+			},
+			want: "package example\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n\nconst x = 0x1F\n",
+		},
+		{
+			name: "empty lines before the closing parenthesis are removed",
+			args: args{
+				src: "package example\n\nimport (\n\t\"github.com/example/module/pkg/one\"\n\t\"fmt\"\n\n\t// trailing\n\n)\n",
 			},
-			want: []byte(`imports (
-	"github.com/openshift/api/build/v1alpha1",
-	"github.com/openshift/client-go/build/clientset/versioned/typed/build/v1alpha1",
-	"github.com/openshift/imagebuilder",
+			want: "package example\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n\t// trailing\n)\n",
+		},
+		{
+			name: "source that does not parse",
+			args: args{
+				src: "package example\n\nimport (\n",
+			},
+			wantErr:    true,
+			wantErrMsg: "example.go:",
+		},
+	}
+	regExpMatchers, displayOrder, err := groups.Build(organizeGroups, []string{"module", "standard"}, module.Module{Name: "github.com/example/module"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Organize("example.go", []byte(tt.args.src), regExpMatchers, displayOrder, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Organize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("Organize() gotErrMsg = %v, wantErrMsg = %v", err.Error(), tt.wantErrMsg)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("Organize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
 
-	"github.com/openshift/imagebuilder/dockerfile/command",
-	"github.com/openshift/imagebuilder/dockerfile/parser",
+func TestOrganizeSort(t *testing.T) {
+	src := "package example\n\nimport (\n\t\"os\"\n\t\"github.com/Zed/x\"\n\tf \"fmt\"\n\t\"encoding/json\"\n\t\"github.com/abc/y\"\n)\n"
+	tests := []struct {
+		name string
+		sort v1beta1.Sort
+		want string
+	}{
+		{
+			name: "lexical",
+			want: "package example\n\nimport (\n\t\"encoding/json\"\n\tf \"fmt\"\n\t\"os\"\n\n\t\"github.com/Zed/x\"\n\t\"github.com/abc/y\"\n)\n",
+		},
+		{
+			name: "segments",
+			sort: v1beta1.Sort{Order: v1beta1.SortOrderSegments},
+			want: "package example\n\nimport (\n\tf \"fmt\"\n\t\"os\"\n\n\t\"encoding/json\"\n\n\t\"github.com/Zed/x\"\n\t\"github.com/abc/y\"\n)\n",
+		},
+		{
+			name: "case insensitive",
+			sort: v1beta1.Sort{Order: v1beta1.SortOrderCaseInsensitive},
+			want: "package example\n\nimport (\n\t\"encoding/json\"\n\tf \"fmt\"\n\t\"os\"\n\n\t\"github.com/abc/y\"\n\n\t\"github.com/Zed/x\"\n)\n",
+		},
+		{
+			name: "segments with named imports first",
+			sort: v1beta1.Sort{Order: v1beta1.SortOrderSegments, Named: v1beta1.SortNamedFirst},
+			want: "package example\n\nimport (\n\tf \"fmt\"\n\n\t\"os\"\n\n\t\"encoding/json\"\n\n\t\"github.com/Zed/x\"\n\t\"github.com/abc/y\"\n)\n",
+		},
+		{
+			name: "named imports last",
+			sort: v1beta1.Sort{Named: v1beta1.SortNamedLast},
+			want: "package example\n\nimport (\n\t\"encoding/json\"\n\t\"os\"\n\n\tf \"fmt\"\n\n\t\"github.com/Zed/x\"\n\t\"github.com/abc/y\"\n)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortGroups := []v1beta1.Group{
+				{
+					Name:    "standard",
+					RegExp:  []string{`^[a-zA-Z0-9\/]+$`},
+					Options: v1beta1.GroupOptions{Sort: tt.sort},
+				},
+				{
+					Name:    "other",
+					RegExp:  []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+					Options: v1beta1.GroupOptions{Sort: tt.sort},
+				},
+			}
+			regExpMatchers, displayOrder, err := groups.Build(sortGroups, []string{"standard", "other"}, module.Module{Name: "github.com/example/module"})
+			if err != nil {
+				t.Fatalf("groups.Build() error = %v", err)
+			}
+			got, err := Organize("example.go", []byte(src), regExpMatchers, displayOrder, false)
+			if err != nil {
+				t.Fatalf("Organize() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Organize() = %q, want %q", got, tt.want)
+			}
+			// gofmt must keep the order of the organized imports
+			formatted, err := format.Source(got)
+			if err != nil {
+				t.Fatalf("format.Source() error = %v", err)
+			}
+			if !bytes.Equal(formatted, got) {
+				t.Errorf("format.Source() = %q, want %q", formatted, got)
+			}
+		})
+	}
+}
 
-	"github.com/openshift/library-go/pkg/git",
-	"github.com/openshift/library-go/pkg/image/reference",
-	"github.com/openshift/source-to-image/pkg/scm/git",
+func TestFormatCgo(t *testing.T) {
+	cgoGroups := []v1beta1.Group{
+		{
+			Name:   "standard",
+			RegExp: []string{`^[a-zA-Z0-9\/]+$`},
+		},
+		{
+			Name:   "other",
+			RegExp: []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+		},
+		{
+			Name:   "module",
+			RegExp: []string{"%{module}%"},
+		},
+	}
+	tests := []struct {
+		name string
+		file string
+	}{
+		{
+			name: "preamble between import declarations",
+			file: "../../test/testdata/imports/cgo/preamble.go",
+		},
+		{
+			name: "preamble before import declarations",
+			file: "../../test/testdata/imports/cgo/first.go",
+		},
+		{
+			name: "cgo import grouped with other imports",
+			file: "../../test/testdata/imports/cgo/grouped.go",
+		},
+	}
+	regExpMatchers, displayOrder, err := groups.Build(cgoGroups, []string{"module", "standard", "other"}, module.Module{Name: "github.com/example/module"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("unable to read %s: %s", tt.file, err.Error())
+			}
+			want, err := os.ReadFile(tt.file + ".golden")
+			if err != nil {
+				t.Fatalf("unable to read %s.golden: %s", tt.file, err.Error())
+			}
+			path := filepath.Join(t.TempDir(), filepath.Base(tt.file))
+			if err := os.WriteFile(path, src, 0644); err != nil {
+				t.Fatalf("unable to write %s: %s", path, err.Error())
+			}
 
-	"github.com/openshift/source-to-image/pkg/util",
-)
-`),
+			result := formatFile(path, Rules{GroupRegExpMatchers: regExpMatchers, DisplayOrder: displayOrder}, nil, false, false)
+			if result.Err != nil {
+				t.Fatalf("formatFile() error = %v", result.Err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unable to read %s: %s", path, err.Error())
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("formatFile() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestFormatDirectives(t *testing.T) {
+	directiveGroups := []v1beta1.Group{
+		{
+			Name:   "standard",
+			RegExp: []string{`^[a-zA-Z0-9\/]+$`},
 		},
 		{
-			name: "mixed imports",
-			args: args{
-				input: []byte(`imports (
-	"fmt",
-	"io",
-	"reflect",
-	"sort",
-	"k8s.io/api/core/v1alpha1",
-	"k8s.io/apimachinery/pkg/api/errors",
-	"k8s.io/apimachinery/pkg/apis/meta/v1alpha1",
-	"k8s.io/apimachinery/pkg/util/sets",
-	"k8s.io/apimachinery/pkg/util/uuid",
-	"k8s.io/apimachinery/pkg/util/wait",
-	"github.com/openshift/api/build/v1alpha1",
-	"github.com/openshift/client-go/build/clientset/versioned/typed/build/v1alpha1",
-	"github.com/openshift/imagebuilder",
-	"github.com/openshift/imagebuilder/dockerfile/command",
-	"github.com/openshift/imagebuilder/dockerfile/parser",
-	"github.com/openshift/library-go/pkg/git",
-	"github.com/openshift/library-go/pkg/image/reference",
-	"github.com/openshift/source-to-image/pkg/scm/git",
-	"github.com/openshift/source-to-image/pkg/util",
-)
-`),
-				breaks: []string{
-					"k8s.io/apimachinery/pkg/util/sets",
-					"github.com/openshift/api/build/v1alpha1",
-					"github.com/openshift/imagebuilder/dockerfile/parser",
-					"github.com/openshift/source-to-image/pkg/scm/git",
-				},
-			},
-			want: []byte(`imports (
-	"fmt",
-	"io",
-	"reflect",
-	"sort",
-	"k8s.io/api/core/v1alpha1",
-	"k8s.io/apimachinery/pkg/api/errors",
-	"k8s.io/apimachinery/pkg/apis/meta/v1alpha1",
-
-	"k8s.io/apimachinery/pkg/util/sets",
-	"k8s.io/apimachinery/pkg/util/uuid",
-	"k8s.io/apimachinery/pkg/util/wait",
-
-	"github.com/openshift/api/build/v1alpha1",
-	"github.com/openshift/client-go/build/clientset/versioned/typed/build/v1alpha1",
-	"github.com/openshift/imagebuilder",
-	"github.com/openshift/imagebuilder/dockerfile/command",
-
-	"github.com/openshift/imagebuilder/dockerfile/parser",
-	"github.com/openshift/library-go/pkg/git",
-	"github.com/openshift/library-go/pkg/image/reference",
-
-	"github.com/openshift/source-to-image/pkg/scm/git",
-	"github.com/openshift/source-to-image/pkg/util",
-)
-`),
+			Name:   "other",
+			RegExp: []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+		},
+		{
+			Name:   "module",
+			RegExp: []string{"%{module}%"},
 		},
 	}
+	tests := []struct {
+		name       string
+		file       string
+		wantStatus string
+	}{
+		{
+			name:       "imports between off and on are pinned",
+			file:       "../../test/testdata/imports/directives/pinned.go",
+			wantStatus: StatusChanged,
+		},
+		{
+			name:       "off without on pins the rest of the import block",
+			file:       "../../test/testdata/imports/directives/unterminated.go",
+			wantStatus: StatusChanged,
+		},
+		{
+			name:       "ignore directive skips the file",
+			file:       "../../test/testdata/imports/directives/ignore.go",
+			wantStatus: StatusSkippedIgnored,
+		},
+	}
+	regExpMatchers, displayOrder, err := groups.Build(directiveGroups, []string{"module", "standard", "other"}, module.Module{Name: "github.com/example/module"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var buf bytes.Buffer
-			buf.Write(tt.args.input)
-			got, err := AddSpaces(bytes.NewReader(buf.Bytes()), tt.args.breaks)
+			src, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("unable to read %s: %s", tt.file, err.Error())
+			}
+			want, err := os.ReadFile(tt.file + ".golden")
+			if err != nil {
+				t.Fatalf("unable to read %s.golden: %s", tt.file, err.Error())
+			}
+			path := filepath.Join(t.TempDir(), filepath.Base(tt.file))
+			if err := os.WriteFile(path, src, 0644); err != nil {
+				t.Fatalf("unable to write %s: %s", path, err.Error())
+			}
+
+			result := formatFile(path, Rules{GroupRegExpMatchers: regExpMatchers, DisplayOrder: displayOrder}, nil, false, false)
+			if result.Err != nil {
+				t.Fatalf("formatFile() error = %v", result.Err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("formatFile() status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			got, err := os.ReadFile(path)
 			if err != nil {
-				t.Errorf("%s", err.Error())
+				t.Fatalf("unable to read %s: %s", path, err.Error())
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("formatFile() = %s, want %s", got, want)
 			}
 
-			if !bytes.Equal(got, tt.want) {
-				t.Errorf("AddSpaces() = %s, want %s", got, tt.want)
+			// Organizing the result again leaves it unchanged
+			if result = formatFile(path, Rules{GroupRegExpMatchers: regExpMatchers, DisplayOrder: displayOrder}, nil, true, false); result.Status == StatusChanged {
+				t.Errorf("formatFile() changed the organized file")
 			}
 		})
 	}
 }
 
-func TestFormat(t *testing.T) {
-	type args struct {
-		regExpMatchers []v1alpha1.RegExpMatcher
-		displayOrder   []string
-		listOnly       *bool
+func TestOrganizePinned(t *testing.T) {
+	pinnedGroups := []v1beta1.Group{
+		{
+			Name:   "standard",
+			RegExp: []string{`^[a-zA-Z0-9\/]+$`},
+		},
+		{
+			Name:   "module",
+			RegExp: []string{"%{module}%"},
+		},
 	}
+	want := "package example\n\nimport (\n\t\"os\"\n\n\t//goio:off\n\t_ \"github.com/example/module/pkg/zzz\"\n\n\t_ \"github.com/example/module/pkg/aaa\"\n\t//goio:on\n)\n"
 	tests := []struct {
 		name string
-		args args
+		src  string
 	}{
-		// TODO: Add test cases.
+		{
+			name: "pinned imports that gofmt would sort",
+			src:  "package example\n\nimport (\n\t\"os\"\n\t//goio:off\n\t_ \"github.com/example/module/pkg/zzz\"\n\t_ \"github.com/example/module/pkg/aaa\"\n\t//goio:on\n)\n",
+		},
+		{
+			name: "pinned imports that are not indented",
+			src:  "package example\nimport (\n\"os\"\n//goio:off\n_ \"github.com/example/module/pkg/zzz\"\n_ \"github.com/example/module/pkg/aaa\"\n//goio:on\n)\n",
+		},
+	}
+	regExpMatchers, displayOrder, err := groups.Build(pinnedGroups, []string{"module", "standard"}, module.Module{Name: "github.com/example/module"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			wg := sync.WaitGroup{}
-			files := make(chan string, 10)
-			resultsChan := make(chan string)
-			hasResults := false
-			defer close(files)
-			Format(&files, &resultsChan, &hasResults, &wg, tt.args.regExpMatchers, tt.args.displayOrder, tt.args.listOnly)
-			wg.Wait()
+			got, err := Organize("example.go", []byte(tt.src), regExpMatchers, displayOrder, false)
+			if err != nil {
+				t.Fatalf("Organize() error = %v", err)
+			}
+			if string(got) != want {
+				t.Errorf("Organize() = %q, want %q", got, want)
+			}
+			// gofmt must keep the order of the pinned imports
+			formatted, err := format.Source(got)
+			if err != nil {
+				t.Fatalf("format.Source() error = %v", err)
+			}
+			if !bytes.Equal(formatted, got) {
+				t.Errorf("format.Source() = %q, want %q", formatted, got)
+			}
 		})
 	}
 }
@@ -260,7 +765,8 @@ func TestFormat(t *testing.T) {
 func TestPopulateGroups(t *testing.T) {
 	type args struct {
 		imports      []*ast.ImportSpec
-		groups       []v1alpha1.Group
+		groups       []v1beta1.Group
+		matchOrder   []string
 		goModuleName string
 	}
 	tests := []struct {
@@ -289,23 +795,21 @@ func TestPopulateGroups(t *testing.T) {
 						},
 					},
 				},
-				groups: []v1alpha1.Group{
+				groups: []v1beta1.Group{
 					{
-						MatchOrder:  0,
-						Description: "module",
-						RegExp:      []string{"%{module}%"},
+						Name:   "module",
+						RegExp: []string{"%{module}%"},
 					},
 					{
-						MatchOrder:  1,
-						Description: "standard",
-						RegExp:      []string{`^[a-zA-Z0-9\\/]+$`},
+						Name:   "standard",
+						RegExp: []string{`^[a-zA-Z0-9\\/]+$`},
 					},
 					{
-						MatchOrder:  2,
-						Description: "other",
-						RegExp:      []string{`[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/`},
+						Name:   "other",
+						RegExp: []string{`[a-zA-Z0-9]+\\.[a-zA-Z0-9]+/`},
 					},
-				},
+				}, matchOrder: []string{"module", "standard", "other"},
+
 				goModuleName: "github.com/exampleOne/module",
 			},
 			wantErr: false,
@@ -330,7 +834,10 @@ func TestPopulateGroups(t *testing.T) {
 
 	for _, tt := range tests {
 		importGroups := make(map[string][]ast.ImportSpec)
-		groupRegExpMatchers, _ := groups.Build(tt.args.groups, tt.args.goModuleName)
+		groupRegExpMatchers, _, err := groups.Build(tt.args.groups, tt.args.matchOrder, module.Module{Name: tt.args.goModuleName})
+		if err != nil {
+			t.Fatalf("groups.Build() error = %v", err)
+		}
 		t.Run(tt.name, func(t *testing.T) {
 			if err := PopulateGroups(importGroups, groupRegExpMatchers, tt.args.imports); (err != nil) != tt.wantErr {
 				t.Errorf("PopulateGroups() error = %v, wantErr %v", err, tt.wantErr)
@@ -357,28 +864,314 @@ func TestPopulateGroups(t *testing.T) {
 }
 
 func TestInsertGroups(t *testing.T) {
+	insertGroups := []v1beta1.Group{
+		{
+			Name:    "standard",
+			RegExp:  []string{`^[a-zA-Z0-9\/]+$`},
+			Options: v1beta1.GroupOptions{Sort: v1beta1.Sort{SeparateBlankAndDot: true}},
+		},
+		{
+			Name:   "other",
+			RegExp: []string{`[a-zA-Z0-9]+\.[a-zA-Z0-9]+/`},
+		},
+		{
+			Name:   "module",
+			RegExp: []string{"%{module}%"},
+		},
+	}
 	type args struct {
-		f            *ast.File
-		importGroups map[string][]ast.ImportSpec
-		displayOrder []string
+		src string
 	}
 	tests := []struct {
 		name    string
 		args    args
-		want    []string
+		want    string
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "groups are separated by empty lines",
+			args: args{
+				src: `package example
+
+import (
+	"fmt"
+	"github.com/example/module/pkg/one"
+	"k8s.io/api/core/v1"
+	"os"
+)
+`,
+			},
+			want: `package example
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/api/core/v1"
+
+	"github.com/example/module/pkg/one"
+)
+`,
+		},
+		{
+			name: "empty groups do not add empty lines",
+			args: args{
+				src: `package example
+
+import (
+	"github.com/example/module/pkg/one"
+
+	"k8s.io/api/core/v1"
+)
+`,
+			},
+			want: `package example
+
+import (
+	"k8s.io/api/core/v1"
+
+	"github.com/example/module/pkg/one"
+)
+`,
+		},
+		{
+			name: "import block followed by comments and matching strings",
+			args: args{
+				src: `package example
+
+import (
+	"os"
+	"k8s.io/api/core/v1"
+	"fmt"
+)
+// paths lists import paths
+var paths = []string{
+	"k8s.io/api/core/v1",
+	"fmt",
+}
+`,
+			},
+			want: `package example
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/api/core/v1"
+)
+
+// paths lists import paths
+var paths = []string{
+	"k8s.io/api/core/v1",
+	"fmt",
+}
+`,
+		},
+		{
+			name: "doc comments stay above the first import of a group",
+			args: args{
+				src: `package example
+
+import (
+	// fmt is documented
+	"fmt"
+	// v1 is documented
+	"k8s.io/api/core/v1"
+	_ "k8s.io/api/core/v1"
+)
+`,
+			},
+			want: `package example
+
+import (
+	// fmt is documented
+	"fmt"
+
+	// v1 is documented
+	"k8s.io/api/core/v1"
+	_ "k8s.io/api/core/v1"
+)
+`,
+		},
+		{
+			name: "blank imports are separated within their group",
+			args: args{
+				src: `package example
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/example/module/pkg/one"
+	"os"
+)
+`,
+			},
+			want: `package example
+
+import (
+	"fmt"
+	"os"
+
+	_ "embed"
+
+	"github.com/example/module/pkg/one"
+)
+`,
+		},
+	}
+	regExpMatchers, displayOrder, err := groups.Build(insertGroups, []string{"module", "standard", "other"}, module.Module{Name: "github.com/example/module"})
+	if err != nil {
+		t.Fatalf("groups.Build() error = %v", err)
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := InsertGroups(tt.args.f, tt.args.importGroups, tt.args.displayOrder)
+			fs := token.NewFileSet()
+			f, err := parser.ParseFile(fs, "example.go", tt.args.src, parser.ParseComments)
+			if err != nil {
+				t.Fatalf("unable to parse source: %s", err.Error())
+			}
+			gen := f.Decls[0].(*ast.GenDecl)
+			importGroups := make(map[string][]ast.ImportSpec)
+			if err := PopulateGroups(importGroups, regExpMatchers, f.Imports); err != nil {
+				t.Fatalf("PopulateGroups() error = %v", err)
+			}
+			sorts := map[string]v1alpha1.Sort{}
+			for _, r := range regExpMatchers {
+				sorts[r.Bucket] = r.Sort
+			}
+			err = InsertGroups(fs, f, gen, importGroups, displayOrder, sorts)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("InsertGroups() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("InsertGroups() = %v, want %v", got, tt.want)
+			var buf bytes.Buffer
+			if err := format.Node(&buf, fs, f); err != nil {
+				t.Fatalf("unable to print source: %s", err.Error())
+			}
+			if buf.String() != tt.want {
+				t.Errorf("InsertGroups() = %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMovedImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldSrc  string
+		newSrc  string
+		want    []MovedImport
+		wantErr bool
+	}{
+		{
+			name:   "unchanged order",
+			oldSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"os\"\n)\n",
+			want:   []MovedImport{},
+		},
+		{
+			name:   "moved to another group",
+			oldSrc: "package main\n\nimport (\n\t\"github.com/example/module/pkg/one\"\n\t\"fmt\"\n\t\"os\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n",
+			want: []MovedImport{
+				{Path: "github.com/example/module/pkg/one", From: 4, To: 7},
+			},
+		},
+		{
+			name:   "named import",
+			oldSrc: "package main\n\nimport (\n\tb \"os\"\n\t\"fmt\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\tb \"os\"\n)\n",
+			want: []MovedImport{
+				{Name: "b", Path: "os", From: 4, To: 5},
+			},
+		},
+		{
+			name:    "invalid source",
+			oldSrc:  "package main\n\nimport (\n",
+			newSrc:  "package main\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MovedImports("main.go", []byte(tt.oldSrc), []byte(tt.newSrc))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MovedImports() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("MovedImports() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("MovedImports() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		oldSrc string
+		newSrc string
+		want   []Block
+	}{
+		{
+			name:   "organized",
+			oldSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n",
+			want:   []Block{},
+		},
+		{
+			name:   "wrong group",
+			oldSrc: "package main\n\nimport (\n\t\"github.com/example/module/pkg/one\"\n\t\"fmt\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n",
+			want: []Block{
+				{Rule: RuleWrongGroup, StartLine: 3, EndLine: 6, Offset: 14, Length: 54, Replacement: "import (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n)"},
+			},
+		},
+		{
+			name:   "wrong order",
+			oldSrc: "package main\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n",
+			want: []Block{
+				{Rule: RuleWrongOrder, StartLine: 3, EndLine: 6, Offset: 14, Length: 23, Replacement: "import (\n\t\"fmt\"\n\t\"os\"\n)"},
+			},
+		},
+		{
+			name:   "missing separator",
+			oldSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"github.com/example/module/pkg/one\"\n)\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n)\n",
+			want: []Block{
+				{Rule: RuleMissingSeparator, StartLine: 3, EndLine: 6, Offset: 14, Length: 54, Replacement: "import (\n\t\"fmt\"\n\n\t\"github.com/example/module/pkg/one\"\n)"},
+			},
+		},
+		{
+			name:   "merged declarations",
+			oldSrc: "package main\n\nimport \"fmt\"\nimport \"os\"\n",
+			newSrc: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n",
+			want: []Block{
+				{Rule: RuleMissingSeparator, StartLine: 3, EndLine: 4, Offset: 14, Length: 24, Replacement: "import (\n\t\"fmt\"\n\t\"os\"\n)"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Blocks("main.go", []byte(tt.oldSrc), []byte(tt.newSrc))
+			if err != nil {
+				t.Fatalf("Blocks() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Blocks() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Blocks() = %#v, want %#v", got[i], tt.want[i])
+				}
 			}
 		})
 	}
@@ -0,0 +1,257 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package imports
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/go-imports-organizer/goio/pkg/sorter"
+)
+
+// errImportBlockTooSmall is returned when the organized imports can not be laid
+// out within the bytes of the original import block, this only happens for
+// import blocks that have not been formatted with gofmt
+var errImportBlockTooSmall = errors.New("import block is too small to hold the organized imports")
+
+// chunk is a contiguous range of the original source that is moved as a whole,
+// it holds an ImportSpec together with its doc comment, its line comment and
+// any floating comments that preceded it, or all of the ImportSpecs and
+// comments of a pinned range
+type chunk struct {
+	// start and end are the offsets of the chunk in the original source
+	start, end int
+	// specs is empty for comments that trail the last ImportSpec of the block
+	specs    []*ast.ImportSpec
+	comments []*ast.CommentGroup
+	// splits are the offsets of the ImportSpecs of a pinned range that start a
+	// new line below an empty line
+	splits []int
+}
+
+// first returns the first ImportSpec of the chunk, or nil when it has none
+func (c *chunk) first() *ast.ImportSpec {
+	if len(c.specs) == 0 {
+		return nil
+	}
+	return c.specs[0]
+}
+
+// gofmtSplits sets the splits of a pinned chunk to the ImportSpecs that gofmt
+// would sort before the ImportSpec on the line above them, an empty line above
+// them keeps gofmt from reordering the pinned ImportSpecs. The empty line is
+// made from the indentation of the ImportSpec, false is returned when an
+// ImportSpec that needs one is not indented.
+func (c *chunk) gofmtSplits(tf *token.File) bool {
+	for i := 1; i < len(c.specs); i++ {
+		prev, spec := c.specs[i-1], c.specs[i]
+		line := tf.Line(spec.Pos())
+		if line != tf.Line(prev.End())+1 || !sorter.ReorderedByGofmt(*prev, *spec) {
+			continue
+		}
+		if tf.Offset(tf.LineStart(line)) == tf.Offset(spec.Pos()) {
+			return false
+		}
+		c.splits = append(c.splits, tf.Offset(spec.Pos()))
+	}
+	return true
+}
+
+// shift moves every position within the chunk by delta
+func (c *chunk) shift(delta token.Pos) {
+	for _, spec := range c.specs {
+		if spec.Name != nil {
+			spec.Name.NamePos += delta
+		}
+		spec.Path.ValuePos += delta
+		if spec.EndPos != 0 {
+			spec.EndPos += delta
+		}
+	}
+	for _, cg := range c.comments {
+		for _, comment := range cg.List {
+			comment.Slash += delta
+		}
+	}
+}
+
+// layoutImports positions the specs of a parenthesized import declaration one
+// per line in the given order, with an empty line above every spec in breaks.
+// Each spec keeps its doc comment, line comment and any floating comments
+// directly above it, the comments are moved along with the spec by rewriting
+// the line table of the token.File so that the printer places them next to the
+// spec again and separates the groups exactly where the empty lines are. The
+// specs of a pinned range are kept together with the comments of the range and
+// their lines are moved as a whole, apart from an empty line above the pinned
+// specs that gofmt would otherwise sort before the spec above them.
+func layoutImports(fs *token.FileSet, f *ast.File, gen *ast.GenDecl, specs []*ast.ImportSpec, breaks map[*ast.ImportSpec]bool, pinned []pinnedRange) error {
+	tf := fs.File(gen.Lparen)
+	if tf == nil {
+		return errors.New("unable to find the file of the import declaration")
+	}
+	lparen, rparen := tf.Offset(gen.Lparen), tf.Offset(gen.Rparen)
+	lparenLine := tf.Line(gen.Lparen)
+	offset := func(p token.Pos) int { return tf.Offset(p) }
+
+	// The comments of a pinned range, including the directives, belong to the
+	// range even when they are attached to an ImportSpec outside of it
+	owned := map[*ast.CommentGroup]bool{}
+	pinnedChunks := map[pinnedRange]*chunk{}
+	for _, spec := range specs {
+		r, ok := pinnedRangeOf(pinned, spec.Pos())
+		if !ok || pinnedChunks[r] != nil {
+			continue
+		}
+		c := &chunk{start: offset(r.start), end: offset(r.end)}
+		for _, cg := range f.Comments {
+			if _, ok := pinnedRangeOf([]pinnedRange{r}, cg.Pos()); ok {
+				c.comments = append(c.comments, cg)
+				owned[cg] = true
+			}
+		}
+		pinnedChunks[r] = c
+	}
+
+	chunks := make([]*chunk, 0, len(specs))
+	for _, spec := range specs {
+		if r, ok := pinnedRangeOf(pinned, spec.Pos()); ok {
+			c := pinnedChunks[r]
+			if len(c.specs) == 0 {
+				chunks = append(chunks, c)
+			}
+			c.specs = append(c.specs, spec)
+			continue
+		}
+		c := &chunk{specs: []*ast.ImportSpec{spec}, start: offset(spec.Pos()), end: offset(spec.End())}
+		if spec.Doc != nil && !owned[spec.Doc] {
+			c.start = offset(spec.Doc.Pos())
+			c.comments = append(c.comments, spec.Doc)
+			owned[spec.Doc] = true
+		}
+		if spec.Comment != nil && !owned[spec.Comment] {
+			c.end = offset(spec.Comment.End())
+			c.comments = append(c.comments, spec.Comment)
+			owned[spec.Comment] = true
+		}
+		chunks = append(chunks, c)
+	}
+
+	// cursor is the offset at which the next chunk will be placed, comments on
+	// the same line as the opening parenthesis stay where they are
+	cursor := lparen + 1
+	var trailing *chunk
+	for _, cg := range f.Comments {
+		if cg.Pos() <= gen.Lparen || cg.Pos() >= gen.Rparen || owned[cg] {
+			continue
+		}
+		if tf.Line(cg.Pos()) == lparenLine {
+			cursor = max(cursor, offset(cg.End())+1)
+			continue
+		}
+		// Floating comments belong to the spec that follows them
+		var next *chunk
+		for _, c := range chunks {
+			if c.first().Pos() > cg.Pos() && (next == nil || c.first().Pos() < next.first().Pos()) {
+				next = c
+			}
+		}
+		if next == nil {
+			if trailing == nil {
+				trailing = &chunk{start: offset(cg.Pos()), end: offset(cg.End())}
+			}
+			next = trailing
+		}
+		next.start = min(next.start, offset(cg.Pos()))
+		next.end = max(next.end, offset(cg.End()))
+		next.comments = append(next.comments, cg)
+	}
+	if trailing != nil {
+		chunks = append(chunks, trailing)
+	}
+
+	// Make sure that the chunks fit between the parenthesis, one line each and
+	// an empty line between the groups
+	needed := cursor
+	for i, c := range chunks {
+		if c.start <= lparen || c.end > rparen {
+			return errors.New("import spec is outside of its import declaration")
+		}
+		if i > 0 {
+			needed++
+			if breaks[c.first()] {
+				needed++
+			}
+		}
+		needed += c.end - c.start
+		if !c.gofmtSplits(tf) {
+			return errImportBlockTooSmall
+		}
+	}
+	if needed > rparen {
+		return errImportBlockTooSmall
+	}
+
+	lines := tf.Lines()
+	newLines := []int{}
+	for _, l := range lines {
+		if l < cursor {
+			newLines = append(newLines, l)
+		}
+	}
+	for i, c := range chunks {
+		if i > 0 {
+			cursor++
+			if breaks[c.first()] {
+				newLines = append(newLines, cursor)
+				cursor++
+			}
+		}
+		delta := cursor - c.start
+		chunkLines := append([]int{}, c.splits...)
+		for _, l := range lines {
+			if l > c.start && l < c.end {
+				chunkLines = append(chunkLines, l)
+			}
+		}
+		sort.Ints(chunkLines)
+		newLines = append(newLines, cursor)
+		for _, l := range chunkLines {
+			newLines = append(newLines, l+delta)
+		}
+		c.shift(token.Pos(delta))
+		cursor += c.end - c.start
+	}
+	// The closing parenthesis always starts a new line
+	if newLines[len(newLines)-1] < rparen {
+		newLines = append(newLines, rparen)
+	}
+	for _, l := range lines {
+		if l > rparen {
+			newLines = append(newLines, l)
+		}
+	}
+	if !tf.SetLines(newLines) {
+		return errors.New("unable to update the line table")
+	}
+
+	// The printer expects the comments in the order that they appear
+	sort.SliceStable(f.Comments, func(i, j int) bool {
+		return f.Comments[i].Pos() < f.Comments[j].Pos()
+	})
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package imports
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+// MovedImport is an import that had to be moved to organize a file
+type MovedImport struct {
+	// Name is the package name given to the import, if any
+	Name string `json:"name,omitempty"`
+	// Path is the unquoted import path
+	Path string `json:"path"`
+	// From is the line of the import before it was organized
+	From int `json:"from"`
+	// To is the line of the import after it was organized
+	To int `json:"to"`
+}
+
+// importLine is an import together with the line that it is on
+type importLine struct {
+	name string
+	path string
+	line int
+}
+
+// key identifies an import regardless of where it is
+func (i importLine) key() string {
+	return i.name + " " + i.path
+}
+
+// MovedImports compares the imports of the old and the new source and returns
+// the imports that changed their order. Imports that kept their relative order
+// are not reported even if their line changed, e.g. because an empty line was
+// inserted above them.
+func MovedImports(path string, oldSrc, newSrc []byte) ([]MovedImport, error) {
+	oldImports, err := importLines(path, oldSrc)
+	if err != nil {
+		return nil, err
+	}
+	newImports, err := importLines(path, newSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// oldImports[i:] and newImports[j:]
+	lcs := make([][]int, len(oldImports)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newImports)+1)
+	}
+	for i := len(oldImports) - 1; i >= 0; i-- {
+		for j := len(newImports) - 1; j >= 0; j-- {
+			if oldImports[i].key() == newImports[j].key() {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Imports that are not part of the common subsequence are the ones that moved
+	var movedOld, movedNew []importLine
+	i, j := 0, 0
+	for i < len(oldImports) && j < len(newImports) {
+		switch {
+		case oldImports[i].key() == newImports[j].key():
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			movedOld = append(movedOld, oldImports[i])
+			i++
+		default:
+			movedNew = append(movedNew, newImports[j])
+			j++
+		}
+	}
+	movedOld = append(movedOld, oldImports[i:]...)
+	movedNew = append(movedNew, newImports[j:]...)
+
+	moved := []MovedImport{}
+	for _, o := range movedOld {
+		for n, candidate := range movedNew {
+			if candidate.key() == o.key() {
+				moved = append(moved, MovedImport{Name: o.name, Path: o.path, From: o.line, To: candidate.line})
+				movedNew = append(movedNew[:n], movedNew[n+1:]...)
+				break
+			}
+		}
+	}
+	return moved, nil
+}
+
+// importLines returns the imports of the source in the order that they appear
+func importLines(path string, src []byte) ([]importLine, error) {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, path, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse imports of %q: %s", path, err.Error())
+	}
+	lines := []importLine{}
+	for _, i := range f.Imports {
+		unquotedPath, err := strconv.Unquote(i.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unquote %s", i.Path.Value)
+		}
+		l := importLine{path: unquotedPath, line: fs.Position(i.Pos()).Line}
+		if i.Name != nil {
+			l.name = i.Name.Name
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
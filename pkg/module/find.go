@@ -23,6 +23,115 @@ import (
 	"golang.org/x/mod/modfile"
 )
 
+// Module holds the information of a go.mod file that is used to organize imports
+type Module struct {
+	// Name is the module path declared by the go.mod file
+	Name string
+	// Path is the location of the go.mod file on the filesystem
+	Path string
+	// GoVersion is the version declared by the go directive, empty if there is none
+	GoVersion string
+	// Requires are the modules required by the go.mod file
+	Requires []Require
+	// Replaces are the paths of the modules that are replaced by the go.mod file
+	Replaces []string
+	// Workspace are the paths of the modules of the go.work file that uses the
+	// module, empty if the module is not part of a workspace
+	Workspace []string
+}
+
+// Require is a module required by a go.mod file
+type Require struct {
+	// Path is the module path of the requirement
+	Path string
+	// Indirect is true when the requirement is marked with an // indirect comment
+	Indirect bool
+}
+
+// Find finds the Go module that contains the path and reads its go.mod file
+func Find(path string) (Module, error) {
+	name, modulePath, err := FindGoModuleNameAndPath(path)
+	if err != nil {
+		return Module{}, err
+	}
+	goModFile := filepath.Join(modulePath, "go.mod")
+	data, err := os.ReadFile(goModFile)
+	if err != nil {
+		return Module{}, fmt.Errorf("unable to open go.mod file for reading: %v", err)
+	}
+	f, err := modfile.Parse(goModFile, data, nil)
+	if err != nil {
+		return Module{}, fmt.Errorf("unable to parse go.mod file: %v", err)
+	}
+	m := Module{Name: name, Path: modulePath}
+	if f.Go != nil {
+		m.GoVersion = f.Go.Version
+	}
+	for _, r := range f.Require {
+		m.Requires = append(m.Requires, Require{Path: r.Mod.Path, Indirect: r.Indirect})
+	}
+	replaced := map[string]bool{}
+	for _, r := range f.Replace {
+		if !replaced[r.Old.Path] {
+			m.Replaces = append(m.Replaces, r.Old.Path)
+			replaced[r.Old.Path] = true
+		}
+	}
+	if m.Workspace, err = findWorkspace(modulePath); err != nil {
+		return Module{}, err
+	}
+	return m, nil
+}
+
+// findWorkspace finds the go.work file in the path or any of its parent
+// directories, or the one named by the GOWORK environment variable, and returns
+// the paths of the modules that it uses. Nothing is returned when there is no
+// go.work file or GOWORK is set to off.
+func findWorkspace(path string) ([]string, error) {
+	goWorkFile := os.Getenv("GOWORK")
+	switch goWorkFile {
+	case "off":
+		return nil, nil
+	case "":
+		for {
+			if _, err := os.Stat(filepath.Join(path, "go.work")); err == nil {
+				goWorkFile = filepath.Join(path, "go.work")
+				break
+			}
+			parent := filepath.Dir(path)
+			if parent == path {
+				return nil, nil
+			}
+			path = parent
+		}
+	}
+	data, err := os.ReadFile(goWorkFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open go.work file for reading: %v", err)
+	}
+	f, err := modfile.ParseWork(goWorkFile, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse go.work file: %v", err)
+	}
+	workspace := []string{}
+	for _, use := range f.Use {
+		dir := use.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(goWorkFile), dir)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open go.mod file of workspace module %s for reading: %v", use.Path, err)
+		}
+		name := modfile.ModulePath(data)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("unable to determine module of workspace module %s", use.Path)
+		}
+		workspace = append(workspace, name)
+	}
+	return workspace, nil
+}
+
 // FindGoModuleNameAndPath finds the current Go modules name (via the go.mod file)
 // and path (location of the go.mod file on the filesystem)
 func FindGoModuleNameAndPath(path string) (string, string, error) {
@@ -33,7 +142,11 @@ func FindGoModuleNameAndPath(path string) (string, string, error) {
 			}
 		}
 		if _, err := os.Stat(fmt.Sprintf("%s/go.mod", path)); err != nil {
-			path = filepath.Dir(path)
+			parent := filepath.Dir(path)
+			if parent == path {
+				return "", "", fmt.Errorf("unable to find a go.mod file in %s or any of its parent directories", path)
+			}
+			path = parent
 			continue
 		}
 		break
@@ -16,6 +16,7 @@ limitations under the License.
 package module
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -79,6 +80,16 @@ func TestFindGoModuleAndPath(t *testing.T) {
 			wantErr:    true,
 			wantErrMsg: "unable to determine module",
 		},
+		{
+			name: "no go.mod file up to the root directory",
+			args: args{
+				path: "/",
+			},
+			wantModule: "",
+			wantPath:   "",
+			wantErr:    true,
+			wantErrMsg: "unable to find a go.mod file",
+		},
 		{
 			name: "path is module base directory",
 			args: args{
@@ -110,3 +121,49 @@ func TestFindGoModuleAndPath(t *testing.T) {
 		})
 	}
 }
+
+func TestFind(t *testing.T) {
+	got, err := Find("../../test/testdata/findModule/moduleOne/folderOne")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if got.Name != "github.com/example/moduleOne" || !strings.HasSuffix(got.Path, "test/testdata/findModule/moduleOne") || got.GoVersion != "1.20" {
+		t.Errorf("Find() = %+v, want github.com/example/moduleOne with go 1.20", got)
+	}
+	wantRequires := []Require{
+		{Path: "github.com/example/direct"},
+		{Path: "github.com/example/indirect", Indirect: true},
+	}
+	if !reflect.DeepEqual(got.Requires, wantRequires) {
+		t.Errorf("Find() Requires = %+v, want %+v", got.Requires, wantRequires)
+	}
+	if !reflect.DeepEqual(got.Replaces, []string{"github.com/example/replaced"}) {
+		t.Errorf("Find() Replaces = %v, want [github.com/example/replaced]", got.Replaces)
+	}
+	if _, err := Find("../../test/testdata/findModule/moduleThree"); err == nil {
+		t.Errorf("Find() error = nil, want an error for a go.mod file without a module")
+	}
+}
+
+func TestFindWorkspace(t *testing.T) {
+	got, err := Find("../../test/testdata/findWorkspace/moduleA")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if got.Name != "github.com/example/moduleA" {
+		t.Errorf("Find() Name = %s, want github.com/example/moduleA", got.Name)
+	}
+	wantWorkspace := []string{"github.com/example/moduleA", "github.com/example/moduleB"}
+	if !reflect.DeepEqual(got.Workspace, wantWorkspace) {
+		t.Errorf("Find() Workspace = %v, want %v", got.Workspace, wantWorkspace)
+	}
+
+	t.Setenv("GOWORK", "off")
+	got, err = Find("../../test/testdata/findWorkspace/moduleA")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(got.Workspace) != 0 {
+		t.Errorf("Find() Workspace = %v with GOWORK=off, want none", got.Workspace)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/go-imports-organizer/goio/pkg/imports"
+)
+
+const (
+	// FormatText writes the paths or diffs of the files that need to be
+	// organized to stdout and any problems to stderr
+	FormatText string = "text"
+	// FormatJSON writes one JSON record per file to stdout
+	FormatJSON string = "json"
+	// FormatSARIF writes a single SARIF log with one result per import block
+	// that is not organized to stdout
+	FormatSARIF string = "sarif"
+)
+
+// Reporter writes the results of organizing files
+type Reporter interface {
+	// Report writes the outcome of organizing a single file
+	Report(r imports.Result) error
+	// Done is called once after the last result has been reported
+	Done() error
+}
+
+// New returns the Reporter for the given format
+func New(format string, stdout, stderr io.Writer, diffOnly bool) (Reporter, error) {
+	switch format {
+	case FormatText:
+		return &textReporter{stdout: stdout, stderr: stderr, diffOnly: diffOnly}, nil
+	case FormatJSON:
+		return &jsonReporter{encoder: json.NewEncoder(stdout)}, nil
+	case FormatSARIF:
+		return newSarifReporter(stdout), nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, must be one of %s, %s, %s", format, FormatText, FormatJSON, FormatSARIF)
+}
+
+// textReporter writes the results in a human readable form
+type textReporter struct {
+	stdout   io.Writer
+	stderr   io.Writer
+	diffOnly bool
+	// skipped counts the skipped files by their Status
+	skipped map[string]int
+}
+
+func (t *textReporter) Report(r imports.Result) error {
+	var err error
+	switch r.Status {
+	case imports.StatusError:
+		_, err = fmt.Fprintf(t.stderr, "%s\n", r.Err.Error())
+	case imports.StatusSkippedModified:
+		_, err = fmt.Fprintf(t.stderr, "%s: %s\n", r.Path, r.Reason)
+	case imports.StatusSkippedGenerated, imports.StatusSkippedNotGenerated, imports.StatusSkippedIgnored, imports.StatusSkippedConstraints:
+		if t.skipped == nil {
+			t.skipped = map[string]int{}
+		}
+		t.skipped[r.Status]++
+	case imports.StatusChanged:
+		if t.diffOnly {
+			_, err = fmt.Fprintf(t.stdout, "%s", r.Diff)
+		} else {
+			_, err = fmt.Fprintf(t.stdout, "%s\n", r.Path)
+		}
+	}
+	return err
+}
+
+// Done writes a summary of the skipped files to stderr
+func (t *textReporter) Done() error {
+	if n := t.skipped[imports.StatusSkippedGenerated]; n != 0 {
+		if _, err := fmt.Fprintf(t.stderr, "skipped %d generated %s\n", n, plural(n, "file", "files")); err != nil {
+			return err
+		}
+	}
+	if n := t.skipped[imports.StatusSkippedNotGenerated]; n != 0 {
+		if _, err := fmt.Fprintf(t.stderr, "skipped %d %s that %s not generated\n", n, plural(n, "file", "files"), plural(n, "is", "are")); err != nil {
+			return err
+		}
+	}
+	if n := t.skipped[imports.StatusSkippedIgnored]; n != 0 {
+		if _, err := fmt.Fprintf(t.stderr, "skipped %d %s with a goio:ignore directive\n", n, plural(n, "file", "files")); err != nil {
+			return err
+		}
+	}
+	if n := t.skipped[imports.StatusSkippedConstraints]; n != 0 {
+		if _, err := fmt.Fprintf(t.stderr, "excluded %d %s by build constraints\n", n, plural(n, "file", "files")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plural returns the singular form when n is 1 and the plural form otherwise
+func plural(n int, singular string, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
+
+// Record is the JSON representation of the outcome of organizing a single file
+type Record struct {
+	// Path is the path to the Go file
+	Path string `json:"path"`
+	// Status is one of unchanged, changed, error, skipped-modified,
+	// skipped-generated, skipped-not-generated, skipped-ignored or
+	// skipped-constraints
+	Status string `json:"status"`
+	// Reason explains why the file was skipped
+	Reason string `json:"reason,omitempty"`
+	// Error is the reason that the file could not be organized
+	Error string `json:"error,omitempty"`
+	// Moved lists the imports that had to be moved to organize the file
+	Moved []imports.MovedImport `json:"moved"`
+}
+
+// jsonReporter writes one Record per line
+type jsonReporter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonReporter) Report(r imports.Result) error {
+	record := Record{Path: r.Path, Status: r.Status, Reason: r.Reason, Moved: r.Moved}
+	if r.Err != nil {
+		record.Error = r.Err.Error()
+	}
+	if record.Moved == nil {
+		record.Moved = []imports.MovedImport{}
+	}
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonReporter) Done() error {
+	return nil
+}
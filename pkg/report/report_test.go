@@ -0,0 +1,158 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-imports-organizer/goio/pkg/imports"
+)
+
+var results = []imports.Result{
+	{Path: "a.go", Status: imports.StatusUnchanged},
+	{Path: "b.go", Status: imports.StatusChanged, Diff: []byte("--- b.go\n+++ b.go\n"), Moved: []imports.MovedImport{{Path: "os", From: 5, To: 4}}},
+	{Path: "c.go", Status: imports.StatusError, Err: errors.New("c.go:1:1: expected 'package', found 'EOF'")},
+	{Path: "d.go", Status: imports.StatusSkippedModified, Reason: "file was modified while organizing, cowardly refusing to overwrite"},
+	{Path: "f.go", Status: imports.StatusSkippedGenerated, Reason: "generated file"},
+	{Path: "g.go", Status: imports.StatusSkippedIgnored, Reason: "goio:ignore directive"},
+	{Path: "h_windows.go", Status: imports.StatusSkippedConstraints, Reason: "excluded by build constraints"},
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		diffOnly   bool
+		wantStdout string
+		wantStderr string
+		wantErr    bool
+	}{
+		{
+			name:       "text",
+			format:     FormatText,
+			wantStdout: "b.go\n",
+			wantStderr: "c.go:1:1: expected 'package', found 'EOF'\nd.go: file was modified while organizing, cowardly refusing to overwrite\nskipped 1 generated file\nskipped 1 file with a goio:ignore directive\nexcluded 1 file by build constraints\n",
+		},
+		{
+			name:       "text with diffs",
+			format:     FormatText,
+			diffOnly:   true,
+			wantStdout: "--- b.go\n+++ b.go\n",
+			wantStderr: "c.go:1:1: expected 'package', found 'EOF'\nd.go: file was modified while organizing, cowardly refusing to overwrite\nskipped 1 generated file\nskipped 1 file with a goio:ignore directive\nexcluded 1 file by build constraints\n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			wantStdout: `{"path":"a.go","status":"unchanged","moved":[]}
+{"path":"b.go","status":"changed","moved":[{"path":"os","from":5,"to":4}]}
+{"path":"c.go","status":"error","error":"c.go:1:1: expected 'package', found 'EOF'","moved":[]}
+{"path":"d.go","status":"skipped-modified","reason":"file was modified while organizing, cowardly refusing to overwrite","moved":[]}
+{"path":"f.go","status":"skipped-generated","reason":"generated file","moved":[]}
+{"path":"g.go","status":"skipped-ignored","reason":"goio:ignore directive","moved":[]}
+{"path":"h_windows.go","status":"skipped-constraints","reason":"excluded by build constraints","moved":[]}
+`,
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			r, err := New(tt.format, &stdout, &stderr, tt.diffOnly)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, result := range results {
+				if err := r.Report(result); err != nil {
+					t.Fatalf("Report() error = %v", err)
+				}
+			}
+			if err := r.Done(); err != nil {
+				t.Fatalf("Done() error = %v", err)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %s, want %s", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %s, want %s", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestSarifReporter(t *testing.T) {
+	var stdout bytes.Buffer
+	r, err := New(FormatSARIF, &stdout, nil, false)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	blocks := []imports.Block{
+		{Rule: imports.RuleWrongOrder, StartLine: 3, EndLine: 6, Offset: 14, Length: 23, Replacement: "import (\n\t\"fmt\"\n\t\"os\"\n)"},
+	}
+	for _, result := range append(results, imports.Result{Path: "e.go", Status: imports.StatusChanged, Blocks: blocks}) {
+		if err := r.Report(result); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("Report() wrote %s before Done()", stdout.String())
+	}
+	if err := r.Done(); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatalf("unable to decode SARIF log: %s", err.Error())
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("SARIF log version = %s with %d runs, want %s with 1 run", log.Version, len(log.Runs), sarifVersion)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("SARIF log has %d rules, want 3", len(run.Tool.Driver.Rules))
+	}
+	if run.Invocations[0].ExecutionSuccessful {
+		t.Errorf("SARIF log execution successful, want unsuccessful")
+	}
+	if len(run.Invocations[0].ToolExecutionNotifications) != 2 {
+		t.Errorf("SARIF log has %d notifications, want 2", len(run.Invocations[0].ToolExecutionNotifications))
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("SARIF log has %d results, want 1", len(run.Results))
+	}
+	got := run.Results[0]
+	if got.RuleID != imports.RuleWrongOrder {
+		t.Errorf("SARIF result rule = %s, want %s", got.RuleID, imports.RuleWrongOrder)
+	}
+	region := got.Locations[0].PhysicalLocation.Region
+	if got.Locations[0].PhysicalLocation.ArtifactLocation.URI != "e.go" || region.StartLine != 3 || region.EndLine != 6 {
+		t.Errorf("SARIF result location = %+v, want e.go lines 3-6", got.Locations[0].PhysicalLocation)
+	}
+	replacement := got.Fixes[0].ArtifactChanges[0].Replacements[0]
+	if replacement.DeletedRegion.ByteOffset != 14 || replacement.DeletedRegion.ByteLength != 23 || replacement.InsertedContent.Text != blocks[0].Replacement {
+		t.Errorf("SARIF result replacement = %+v, want %+v", replacement, blocks[0])
+	}
+}
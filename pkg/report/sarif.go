@@ -0,0 +1,202 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/go-imports-organizer/goio/pkg/imports"
+	"github.com/go-imports-organizer/goio/pkg/version"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifRules describes the rules that import blocks are checked against
+var sarifRules = []sarifRule{
+	{
+		ID:               imports.RuleWrongGroup,
+		ShortDescription: sarifMessage{Text: "An import is not in the group that it belongs to"},
+	},
+	{
+		ID:               imports.RuleWrongOrder,
+		ShortDescription: sarifMessage{Text: "The imports within a group are not sorted"},
+	},
+	{
+		ID:               imports.RuleMissingSeparator,
+		ShortDescription: sarifMessage{Text: "The import groups are not separated by a single empty line"},
+	},
+}
+
+// The sarif types only hold the parts of the SARIF 2.1.0 format that are used
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine  int `json:"startLine,omitempty"`
+	EndLine    int `json:"endLine,omitempty"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// sarifReporter collects the results and writes them as a single SARIF log
+// once all files have been organized
+type sarifReporter struct {
+	out io.Writer
+	run sarifRun
+}
+
+func newSarifReporter(out io.Writer) *sarifReporter {
+	return &sarifReporter{
+		out: out,
+		run: sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "goio",
+				Version:        version.Get(),
+				InformationURI: "https://github.com/go-imports-organizer/goio",
+				Rules:          sarifRules,
+			}},
+			Invocations: []sarifInvocation{{ExecutionSuccessful: true, ToolExecutionNotifications: []sarifNotification{}}},
+			Results:     []sarifResult{},
+		},
+	}
+}
+
+func (s *sarifReporter) Report(r imports.Result) error {
+	artifact := sarifArtifactLocation{URI: filepath.ToSlash(r.Path)}
+	switch r.Status {
+	case imports.StatusError, imports.StatusSkippedModified:
+		message := r.Reason
+		if r.Err != nil {
+			message = r.Err.Error()
+			s.run.Invocations[0].ExecutionSuccessful = false
+		}
+		s.run.Invocations[0].ToolExecutionNotifications = append(s.run.Invocations[0].ToolExecutionNotifications, sarifNotification{
+			Level:     "error",
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}}},
+		})
+	case imports.StatusChanged:
+		for _, b := range r.Blocks {
+			region := sarifRegion{StartLine: b.StartLine, EndLine: b.EndLine, ByteOffset: b.Offset, ByteLength: b.Length}
+			s.run.Results = append(s.run.Results, sarifResult{
+				RuleID:    b.Rule,
+				Level:     "warning",
+				Message:   sarifMessage{Text: fmt.Sprintf("imports are not organized (%s)", b.Rule)},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact, Region: &region}}},
+				Fixes: []sarifFix{{
+					Description: sarifMessage{Text: "Organize the imports"},
+					ArtifactChanges: []sarifArtifactChange{{
+						ArtifactLocation: artifact,
+						Replacements: []sarifReplacement{{
+							DeletedRegion:   sarifRegion{ByteOffset: b.Offset, ByteLength: b.Length},
+							InsertedContent: sarifMessage{Text: b.Replacement},
+						}},
+					}},
+				}},
+			})
+		}
+	}
+	return nil
+}
+
+func (s *sarifReporter) Done() error {
+	encoder := json.NewEncoder(s.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{s.run}})
+}
@@ -17,6 +17,9 @@ package sorter
 
 import (
 	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
 )
@@ -28,6 +31,140 @@ func (a SortImportsByPathValue) Len() int           { return len(a) }
 func (a SortImportsByPathValue) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a SortImportsByPathValue) Less(i, j int) bool { return a[i].Path.Value < a[j].Path.Value }
 
+// SortImportsBySegments sorts a slice of ImportSpecs by the number of segments
+// in their Path.Value and then by their Path.Value
+type SortImportsBySegments []ast.ImportSpec
+
+func (a SortImportsBySegments) Len() int      { return len(a) }
+func (a SortImportsBySegments) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a SortImportsBySegments) Less(i, j int) bool {
+	si, sj := strings.Count(a[i].Path.Value, "/"), strings.Count(a[j].Path.Value, "/")
+	if si != sj {
+		return si < sj
+	}
+	return a[i].Path.Value < a[j].Path.Value
+}
+
+// SortImportsCaseInsensitive sorts a slice of ImportSpecs by their Path.Value
+// ignoring case
+type SortImportsCaseInsensitive []ast.ImportSpec
+
+func (a SortImportsCaseInsensitive) Len() int      { return len(a) }
+func (a SortImportsCaseInsensitive) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a SortImportsCaseInsensitive) Less(i, j int) bool {
+	li, lj := strings.ToLower(a[i].Path.Value), strings.ToLower(a[j].Path.Value)
+	if li != lj {
+		return li < lj
+	}
+	return a[i].Path.Value < a[j].Path.Value
+}
+
+// SortImports sorts the imports of a group in place according to the Sort
+// options and returns them as one or more blocks that should be separated by
+// an empty line. Every sort class, the number of segments for the segments
+// order, the named imports for named first or last and the blank and dot
+// imports when they are separated, starts a new block, and so does every import
+// that gofmt would sort before the import above it. This way gofmt, which sorts
+// the imports on consecutive lines, keeps the order of the imports. The blocks
+// share the backing array of imports.
+func SortImports(imports []ast.ImportSpec, s v1alpha1.Sort) [][]ast.ImportSpec {
+	switch s.Order {
+	case v1alpha1.SortOrderSegments:
+		sort.Stable(SortImportsBySegments(imports))
+	case v1alpha1.SortOrderCaseInsensitive:
+		sort.Stable(SortImportsCaseInsensitive(imports))
+	default:
+		sort.Stable(SortImportsByPathValue(imports))
+	}
+
+	switch s.Named {
+	case v1alpha1.SortNamedFirst:
+		sort.SliceStable(imports, func(i, j int) bool { return isNamed(imports[i]) && !isNamed(imports[j]) })
+	case v1alpha1.SortNamedLast:
+		sort.SliceStable(imports, func(i, j int) bool { return !isNamed(imports[i]) && isNamed(imports[j]) })
+	}
+
+	if s.SeparateBlankAndDot {
+		sort.SliceStable(imports, func(i, j int) bool { return !isBlankOrDot(imports[i]) && isBlankOrDot(imports[j]) })
+	}
+
+	blocks := [][]ast.ImportSpec{}
+	start := 0
+	for i := 1; i < len(imports); i++ {
+		if sortClass(imports[i-1], s) != sortClass(imports[i], s) || ReorderedByGofmt(imports[i-1], imports[i]) {
+			blocks = append(blocks, imports[start:i])
+			start = i
+		}
+	}
+	if len(imports) != 0 {
+		blocks = append(blocks, imports[start:])
+	}
+	return blocks
+}
+
+// class is the part of an import that the Sort options order the imports by
+// before their path
+type class struct {
+	segments   int
+	named      bool
+	blankOrDot bool
+}
+
+// sortClass returns the class of the import according to the Sort options
+func sortClass(i ast.ImportSpec, s v1alpha1.Sort) class {
+	c := class{}
+	if s.Order == v1alpha1.SortOrderSegments {
+		c.segments = strings.Count(i.Path.Value, "/")
+	}
+	if len(s.Named) != 0 {
+		c.named = isNamed(i)
+	}
+	if s.SeparateBlankAndDot {
+		c.blankOrDot = isBlankOrDot(i)
+	}
+	return c
+}
+
+// ReorderedByGofmt returns true if gofmt would sort the import b before the
+// import a when b directly follows a on the next line. Like gofmt it orders
+// imports by their path and then by their name.
+func ReorderedByGofmt(a ast.ImportSpec, b ast.ImportSpec) bool {
+	pa, pb := importPath(a), importPath(b)
+	if pa != pb {
+		return pb < pa
+	}
+	return importName(b) < importName(a)
+}
+
+// importPath returns the unquoted path of the import, or its Path.Value when it
+// can not be unquoted
+func importPath(i ast.ImportSpec) string {
+	path, err := strconv.Unquote(i.Path.Value)
+	if err != nil {
+		return i.Path.Value
+	}
+	return path
+}
+
+// importName returns the package name that the import is given, or an empty
+// string when it has none
+func importName(i ast.ImportSpec) string {
+	if i.Name == nil {
+		return ""
+	}
+	return i.Name.Name
+}
+
+// isNamed returns true for imports that are given a package name
+func isNamed(i ast.ImportSpec) bool {
+	return i.Name != nil && !isBlankOrDot(i)
+}
+
+// isBlankOrDot returns true for blank (_) and dot (.) imports
+func isBlankOrDot(i ast.ImportSpec) bool {
+	return i.Name != nil && (i.Name.Name == "_" || i.Name.Name == ".")
+}
+
 // SortGroupsByMatchOrder sorts a slice of Group objects using their MatchOrder
 type SortGroupsByMatchOrder []v1alpha1.Group
 
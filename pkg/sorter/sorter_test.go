@@ -19,6 +19,7 @@ import (
 	"go/ast"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	v1alpha1 "github.com/go-imports-organizer/goio/pkg/api/v1alpha1"
@@ -248,3 +249,150 @@ func TestSortGroupsByMatchOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestSortImports(t *testing.T) {
+	type args struct {
+		imports []string
+		sort    v1alpha1.Sort
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]string
+	}{
+		{
+			name: "lexical by default",
+			args: args{
+				imports: []string{`"sort"`, `"Zebra"`, `"fmt"`, `"net/http"`},
+			},
+			want: [][]string{
+				{`"Zebra"`, `"fmt"`, `"net/http"`, `"sort"`},
+			},
+		},
+		{
+			name: "segments",
+			args: args{
+				imports: []string{`"net/http/httptest"`, `"sort"`, `"net/http"`, `"fmt"`},
+				sort:    v1alpha1.Sort{Order: v1alpha1.SortOrderSegments},
+			},
+			want: [][]string{
+				{`"fmt"`, `"sort"`},
+				{`"net/http"`},
+				{`"net/http/httptest"`},
+			},
+		},
+		{
+			name: "case insensitive",
+			args: args{
+				imports: []string{`"sort"`, `"Zebra"`, `"fmt"`, `"Apple"`},
+				sort:    v1alpha1.Sort{Order: v1alpha1.SortOrderCaseInsensitive},
+			},
+			want: [][]string{
+				{`"Apple"`, `"fmt"`, `"sort"`},
+				{`"Zebra"`},
+			},
+		},
+		{
+			name: "named first",
+			args: args{
+				imports: []string{`"sort"`, `b "bytes"`, `"fmt"`, `_ "embed"`, `a "archive/tar"`},
+				sort:    v1alpha1.Sort{Named: v1alpha1.SortNamedFirst},
+			},
+			want: [][]string{
+				{`a "archive/tar"`, `b "bytes"`},
+				{`_ "embed"`, `"fmt"`, `"sort"`},
+			},
+		},
+		{
+			name: "named last",
+			args: args{
+				imports: []string{`"sort"`, `b "bytes"`, `"fmt"`, `a "archive/tar"`},
+				sort:    v1alpha1.Sort{Named: v1alpha1.SortNamedLast},
+			},
+			want: [][]string{
+				{`"fmt"`, `"sort"`},
+				{`a "archive/tar"`, `b "bytes"`},
+			},
+		},
+		{
+			name: "blank and dot imports in their own block",
+			args: args{
+				imports: []string{`"sort"`, `_ "embed"`, `"fmt"`, `. "math"`, `_ "crypto/sha256"`},
+				sort:    v1alpha1.Sort{SeparateBlankAndDot: true},
+			},
+			want: [][]string{
+				{`"fmt"`, `"sort"`},
+				{`_ "crypto/sha256"`, `_ "embed"`, `. "math"`},
+			},
+		},
+		{
+			name: "only blank imports",
+			args: args{
+				imports: []string{`_ "embed"`, `_ "crypto/sha256"`},
+				sort:    v1alpha1.Sort{SeparateBlankAndDot: true},
+			},
+			want: [][]string{
+				{`_ "crypto/sha256"`, `_ "embed"`},
+			},
+		},
+		{
+			name: "segments and named last",
+			args: args{
+				imports: []string{`"os"`, `f "fmt"`, `"net/http"`, `"encoding/json"`},
+				sort:    v1alpha1.Sort{Order: v1alpha1.SortOrderSegments, Named: v1alpha1.SortNamedLast},
+			},
+			want: [][]string{
+				{`"os"`},
+				{`"encoding/json"`, `"net/http"`},
+				{`f "fmt"`},
+			},
+		},
+		{
+			name: "same path with different names",
+			args: args{
+				imports: []string{`b "fmt"`, `a "fmt"`},
+			},
+			want: [][]string{
+				{`b "fmt"`},
+				{`a "fmt"`},
+			},
+		},
+		{
+			name: "no imports",
+			args: args{
+				imports: []string{},
+			},
+			want: [][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imports := []ast.ImportSpec{}
+			for _, s := range tt.args.imports {
+				is := ast.ImportSpec{}
+				if name, path, ok := strings.Cut(s, " "); ok {
+					is.Name = &ast.Ident{Name: name}
+					is.Path = &ast.BasicLit{Value: path}
+				} else {
+					is.Path = &ast.BasicLit{Value: s}
+				}
+				imports = append(imports, is)
+			}
+			got := [][]string{}
+			for _, block := range SortImports(imports, tt.args.sort) {
+				sortedImports := []string{}
+				for _, s := range block {
+					if s.Name != nil {
+						sortedImports = append(sortedImports, s.Name.Name+" "+s.Path.Value)
+					} else {
+						sortedImports = append(sortedImports, s.Path.Value)
+					}
+				}
+				got = append(got, sortedImports)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortImports() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
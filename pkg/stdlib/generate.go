@@ -0,0 +1,267 @@
+//go:build ignore
+
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// generate.go refreshes packages.txt from the standard library of a local Go
+// installation. It uses the GOROOT given as the first argument or the GOROOT
+// of the go command and is run with
+//
+//	go generate ./pkg/stdlib
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"go/version"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// noAPI holds the Go versions that added the packages without an exported
+// API, they are not part of the api files
+var noAPI = map[string]string{
+	"runtime/asan": "go1.18",
+	"runtime/msan": "go1.6",
+	"runtime/race": "go1.1",
+	"syscall/js":   "go1.11",
+	"time/tzdata":  "go1.15",
+	"unsafe":       "go1",
+}
+
+func main() {
+	goroot, err := findGoroot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+	packages, err := findPackages(filepath.Join(goroot, "src"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to find the standard library packages: %s\n", err.Error())
+		os.Exit(1)
+	}
+	added, err := findVersions(filepath.Join(goroot, "api"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to find the versions of the standard library packages: %s\n", err.Error())
+		os.Exit(1)
+	}
+	goVersion, err := os.ReadFile(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read the version of %s: %s\n", goroot, err.Error())
+		os.Exit(1)
+	}
+
+	goVersion = []byte(strings.SplitN(string(goVersion), "\n", 2)[0])
+	previous, err := readVersions("packages.txt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read the versions of the previous list: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Code generated by generate.go from %s. DO NOT EDIT.\n", goVersion)
+	fmt.Fprint(&b, "# Each line holds the Go version that added the package and its import path.\n")
+	for _, p := range packages {
+		v, ok := added[p]
+		if !ok {
+			v, ok = noAPI[p]
+		}
+		if !ok {
+			v, ok = previous[p]
+		}
+		if !ok {
+			// The package first appears in the list of this Go version
+			v = version.Lang(string(goVersion))
+		}
+		fmt.Fprintf(&b, "%s %s\n", v, p)
+	}
+	if err := os.WriteFile("packages.txt", []byte(b.String()), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write to path %q, %s\n", "packages.txt", err.Error())
+		os.Exit(1)
+	}
+}
+
+// findGoroot returns the GOROOT given as the first argument or the one that
+// the go command uses
+func findGoroot() (string, error) {
+	if len(os.Args) > 1 {
+		return os.Args[1], nil
+	}
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine GOROOT: %s", err.Error())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// findPackages returns the import paths of all importable packages below src,
+// commands, internal packages, vendored packages, testdata and packages that
+// only build with a GOEXPERIMENT are left out
+func findPackages(src string) ([]string, error) {
+	found := map[string]bool{}
+	err := filepath.Walk(src, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if f.IsDir() && rel != "." {
+			for _, segment := range strings.Split(rel, "/") {
+				if segment == "internal" || segment == "vendor" || segment == "testdata" || strings.HasPrefix(segment, ".") || strings.HasPrefix(segment, "_") {
+					return filepath.SkipDir
+				}
+			}
+			if rel == "cmd" || rel == "builtin" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(rel, ".go") && !strings.HasSuffix(rel, "_test.go") && strings.Contains(rel, "/") {
+			experimental, err := experimentOnly(path)
+			if err != nil {
+				return err
+			}
+			dir := filepath.ToSlash(filepath.Dir(rel))
+			found[dir] = found[dir] || !experimental
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]string, 0, len(found))
+	for p, ok := range found {
+		if ok {
+			packages = append(packages, p)
+		}
+	}
+	sort.Strings(packages)
+	return packages, nil
+}
+
+// experimentOnly returns true if the //go:build line of the file can only be
+// satisfied with a GOEXPERIMENT, such as goexperiment.arenas or boringcrypto.
+// Every combination of the other build tags of the line is tried.
+func experimentOnly(path string) (bool, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false, err
+	}
+	var expr constraint.Expr
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if constraint.IsGoBuild(c.Text) {
+				if expr, err = constraint.Parse(c.Text); err != nil {
+					return false, fmt.Errorf("%s: %s", path, err.Error())
+				}
+			}
+		}
+	}
+	if expr == nil {
+		return false, nil
+	}
+	isExperiment := func(tag string) bool {
+		return strings.HasPrefix(tag, "goexperiment.") || tag == "boringcrypto"
+	}
+	tags := []string{}
+	expr.Eval(func(tag string) bool {
+		if !isExperiment(tag) && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+		return false
+	})
+	for combination := 0; combination < 1<<len(tags); combination++ {
+		satisfied := expr.Eval(func(tag string) bool {
+			i := slices.Index(tags, tag)
+			return i >= 0 && combination&(1<<i) != 0
+		})
+		if satisfied {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findVersions returns the first Go version that lists each package in its
+// api file
+func findVersions(api string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(api, "go1*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	added := map[string]string{}
+	for _, file := range files {
+		v := strings.TrimSuffix(filepath.Base(file), ".txt")
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, ok := strings.CutPrefix(scanner.Text(), "pkg ")
+			if !ok {
+				continue
+			}
+			p, _, _ := strings.Cut(line, ",")
+			p, _, _ = strings.Cut(p, " ")
+			if current, ok := added[p]; !ok || version.Compare(v, current) < 0 {
+				added[p] = v
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return added, nil
+}
+
+// readVersions returns the Go version of each package of a list that was
+// generated before, a missing list has no versions
+func readVersions(path string) (map[string]string, error) {
+	versions := map[string]string{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if v, p, ok := strings.Cut(line, " "); ok {
+			versions[p] = v
+		}
+	}
+	return versions, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package stdlib knows the packages of the Go standard library. The list of
+// packages is generated from a Go installation and embedded into goio, refresh
+// it from the local GOROOT with
+//
+//	go generate ./pkg/stdlib
+package stdlib
+
+//go:generate go run generate.go
+
+import (
+	_ "embed"
+	"go/version"
+	"regexp"
+	"strings"
+)
+
+//go:embed packages.txt
+var packagesFile string
+
+// Package is a package of the standard library
+type Package struct {
+	// Path is the import path of the package
+	Path string
+	// Since is the Go version that added the package, e.g. go1.21
+	Since string
+}
+
+// All returns every package of the embedded standard library list
+func All() []Package {
+	packages := []Package{}
+	for _, line := range strings.Split(packagesFile, "\n") {
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		since, path, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		packages = append(packages, Package{Path: path, Since: since})
+	}
+	return packages
+}
+
+// Packages returns the import paths of the packages that are part of the
+// standard library of the given Go version, as it is declared by the go
+// directive of a go.mod file, e.g. 1.21 or 1.22.0. All packages are returned
+// when goVersion is empty.
+func Packages(goVersion string) []string {
+	v := "go" + goVersion
+	paths := []string{}
+	for _, p := range All() {
+		if len(goVersion) == 0 || !version.IsValid(v) || version.Compare(p.Since, v) <= 0 {
+			paths = append(paths, p.Path)
+		}
+	}
+	return paths
+}
+
+// RegExp returns a Regular Expression that matches the import paths of the
+// standard library of the given Go version exactly
+func RegExp(goVersion string) string {
+	paths := Packages(goVersion)
+	for i := range paths {
+		paths[i] = regexp.QuoteMeta(paths[i])
+	}
+	return "^(?:" + strings.Join(paths, "|") + ")$"
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stdlib
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPackages(t *testing.T) {
+	tests := []struct {
+		name      string
+		goVersion string
+		want      []string
+		wantNot   []string
+	}{
+		{
+			name:      "all packages",
+			goVersion: "",
+			want:      []string{"fmt", "net/http", "log/slog", "iter"},
+			wantNot:   []string{"internal/abi", "cmd/go", "vendor/golang.org/x/net/http2", "arena", "crypto/boring"},
+		},
+		{
+			name:      "go 1.14",
+			goVersion: "1.14",
+			want:      []string{"fmt", "unsafe", "runtime/race"},
+			wantNot:   []string{"time/tzdata", "embed"},
+		},
+		{
+			name:      "go 1.15",
+			goVersion: "1.15",
+			want:      []string{"fmt", "time/tzdata"},
+			wantNot:   []string{"embed"},
+		},
+		{
+			name:      "go 1.21",
+			goVersion: "1.21",
+			want:      []string{"fmt", "embed", "log/slog"},
+			wantNot:   []string{"iter", "math/rand/v2"},
+		},
+		{
+			name:      "go 1.22.0",
+			goVersion: "1.22.0",
+			want:      []string{"fmt", "log/slog", "math/rand/v2"},
+			wantNot:   []string{"iter"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]bool{}
+			for _, p := range Packages(tt.goVersion) {
+				got[p] = true
+			}
+			for _, p := range tt.want {
+				if !got[p] {
+					t.Errorf("Packages(%q) is missing %s", tt.goVersion, p)
+				}
+			}
+			for _, p := range tt.wantNot {
+				if got[p] {
+					t.Errorf("Packages(%q) contains %s", tt.goVersion, p)
+				}
+			}
+		})
+	}
+}
+
+func TestRegExp(t *testing.T) {
+	r := regexp.MustCompile(RegExp("1.22"))
+	for _, path := range []string{"fmt", "net/http", "math/rand/v2"} {
+		if !r.MatchString(path) {
+			t.Errorf("RegExp() does not match %s", path)
+		}
+	}
+	for _, path := range []string{"fmtx", "net/httpx", "corp/internal/tool", "github.com/example/module", "iter"} {
+		if r.MatchString(path) {
+			t.Errorf("RegExp() matches %s", path)
+		}
+	}
+}
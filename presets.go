@@ -0,0 +1,55 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+)
+
+// defaultPreset is the preset that is used when no goio.yaml file is found
+const defaultPreset = "default"
+
+// examples holds the example configuration files, each of them is a preset
+// that can be written to disk with goio config init
+//
+//go:embed examples/*.yaml
+var examples embed.FS
+
+// preset returns the contents of the named preset and whether it exists
+func preset(name string) ([]byte, bool) {
+	data, err := examples.ReadFile(path.Join("examples", name+".yaml"))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// presetNames returns the names of all presets
+func presetNames() []string {
+	entries, err := examples.ReadDir("examples")
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
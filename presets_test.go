@@ -0,0 +1,46 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"testing"
+
+	"github.com/go-imports-organizer/goio/pkg/config"
+)
+
+func TestPresets(t *testing.T) {
+	names := presetNames()
+	if len(names) == 0 {
+		t.Fatalf("presetNames() returned no presets")
+	}
+	if _, ok := preset(defaultPreset); !ok {
+		t.Errorf("preset(%q) does not exist", defaultPreset)
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			data, ok := preset(name)
+			if !ok {
+				t.Fatalf("preset(%q) does not exist", name)
+			}
+			if _, err := config.Parse(name, data); err != nil {
+				t.Errorf("preset %q is invalid: %v", name, err)
+			}
+		})
+	}
+	if _, ok := preset("unknown"); ok {
+		t.Errorf("preset(%q) exists, want it to not exist", "unknown")
+	}
+}
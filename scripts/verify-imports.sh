@@ -1,6 +1,6 @@
 #!/bin/bash
 
-bad_files=$(go run main.go -l)
+bad_files=$(go run . -l)
 if [[ -n "${bad_files}" ]]; then
         echo "!!! goio needs to be run on the following files:"
         echo "${bad_files}"
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 Go Imports Organizer Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the goio command instead of the tests when the test binary is
+// executed by runGoio
+func TestMain(m *testing.M) {
+	if os.Getenv("GOIO_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runGoio runs the goio command in the directory dir with stdin as its standard
+// input and returns its standard output and exit code
+func runGoio(t *testing.T, dir string, stdin string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOIO_TEST_MAIN=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("unable to run goio %v: %s", args, err.Error())
+	}
+	if stderr.Len() != 0 {
+		t.Logf("goio %v stderr: %s", args, stderr.String())
+	}
+	return stdout.String(), cmd.ProcessState.ExitCode()
+}
+
+func TestOrganizeStdin(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":         "module example.com/root\n\ngo 1.21\n",
+		"pkg/one/one.go": "package one\n",
+		"goio.yaml":      "apiVersion: goio/v1beta1\nkind: Config\ngroups:\n  - name: standard\n    regexp:\n      - ^[a-zA-Z0-9\\/]+$\n  - name: module\n    regexp:\n      - \"%{module}%\"\nmatchorder:\n  - module\n  - standard\n",
+		// The goio.yaml file of the staging directory displays the module
+		// imports first
+		"staging/goio.yaml": "apiVersion: goio/v1beta1\nkind: Config\ngroups:\n  - name: module\n    regexp:\n      - \"%{module}%\"\n  - name: standard\n    regexp:\n      - ^[a-zA-Z0-9\\/]+$\nmatchorder:\n  - module\n  - standard\n",
+	})
+	// The command runs outside of the module, the module and the goio.yaml
+	// file are found relative to the filename
+	outside := t.TempDir()
+
+	unorganized := "package example\n\nimport (\n\t\"example.com/root/pkg/one\"\n\t\"fmt\"\n)\n"
+	organized := "package example\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/root/pkg/one\"\n)\n"
+	staged := "package example\n\nimport (\n\t\"example.com/root/pkg/one\"\n\n\t\"fmt\"\n)\n"
+	generated := "// Code generated by generator. DO NOT EDIT.\n\n" + unorganized
+
+	tests := []struct {
+		name     string
+		stdin    string
+		args     []string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "writes the organized source",
+			stdin:    unorganized,
+			args:     []string{"-stdin", "-filename", filepath.Join(root, "pkg", "example.go")},
+			want:     organized,
+			wantCode: 0,
+		},
+		{
+			name:     "uses the goio.yaml file of the directory of the filename",
+			stdin:    unorganized,
+			args:     []string{"-stdin", "-filename", filepath.Join(root, "staging", "example.go")},
+			want:     staged,
+			wantCode: 0,
+		},
+		{
+			name:     "writes skipped source unchanged",
+			stdin:    generated,
+			args:     []string{"-stdin", "-filename", filepath.Join(root, "pkg", "generated.go")},
+			want:     generated,
+			wantCode: 0,
+		},
+		{
+			name:     "lists source that needs to be organized",
+			stdin:    unorganized,
+			args:     []string{"-stdin", "-l", "-filename", filepath.Join(root, "pkg", "example.go")},
+			want:     filepath.Join(root, "pkg", "example.go") + "\n",
+			wantCode: 1,
+		},
+		{
+			name:     "lists nothing for organized source",
+			stdin:    organized,
+			args:     []string{"-stdin", "-l", "-filename", filepath.Join(root, "pkg", "example.go")},
+			want:     "",
+			wantCode: 0,
+		},
+		{
+			name:     "displays the diff of source that needs to be organized",
+			stdin:    unorganized,
+			args:     []string{"-stdin", "-d", "-filename", filepath.Join(root, "pkg", "example.go")},
+			want:     "--- " + filepath.Join(root, "pkg", "example.go") + "\n+++ " + filepath.Join(root, "pkg", "example.go") + "\n@@ -1,6 +1,7 @@\n package example\n \n import (\n-\t\"example.com/root/pkg/one\"\n \t\"fmt\"\n+\n+\t\"example.com/root/pkg/one\"\n )\n",
+			wantCode: 1,
+		},
+		{
+			name:     "source that does not parse",
+			stdin:    "package example\n\nimport (\n",
+			args:     []string{"-stdin", "-filename", filepath.Join(root, "pkg", "example.go")},
+			want:     "",
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, code := runGoio(t, outside, tt.stdin, tt.args...)
+			if got != tt.want {
+				t.Errorf("goio %v = %q, want %q", tt.args, got, tt.want)
+			}
+			if code != tt.wantCode {
+				t.Errorf("goio %v exit code = %d, want %d", tt.args, code, tt.wantCode)
+			}
+		})
+	}
+}
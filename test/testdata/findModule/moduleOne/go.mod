@@ -1,3 +1,10 @@
 module github.com/example/moduleOne
 
 go 1.20
+
+require (
+	github.com/example/direct v1.0.0
+	github.com/example/indirect v1.2.0 // indirect
+)
+
+replace github.com/example/replaced => ../replaced
@@ -0,0 +1,3 @@
+module github.com/example/moduleA
+
+go 1.21
@@ -0,0 +1,3 @@
+module github.com/example/moduleB
+
+go 1.21
@@ -0,0 +1,15 @@
+package cgo
+
+// #cgo LDFLAGS: -lm
+// #include <math.h>
+import "C"
+
+import (
+	"fmt"
+	"github.com/example/module/pkg/one"
+	"os"
+)
+
+func main() {
+	fmt.Fprint(os.Stdout, one.Name, C.sqrt(2))
+}
@@ -0,0 +1,18 @@
+package cgo
+
+import (
+	// #include <stdlib.h>
+	"C"
+	"github.com/example/module/pkg/one"
+	"unsafe"
+)
+
+import (
+	"github.com/example/module/pkg/two"
+	"os"
+)
+
+func main() {
+	C.free(unsafe.Pointer(nil))
+	os.Exit(len(one.Name + two.Name))
+}
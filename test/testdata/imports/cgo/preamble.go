@@ -0,0 +1,19 @@
+package cgo
+
+import (
+	"fmt"
+	"github.com/example/module/pkg/one"
+)
+
+/*
+#include <stdio.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import "unsafe"
+import "github.com/example/module/pkg/two"
+
+func main() {
+	fmt.Print(one.Name, two.Name, unsafe.Sizeof(C.int(0)))
+}
@@ -0,0 +1,12 @@
+//goio:ignore the import order is hand-tuned
+
+package directives
+
+import (
+	"github.com/example/module/pkg/one"
+	"os"
+)
+
+func main() {
+	os.Exit(len(one.Name))
+}
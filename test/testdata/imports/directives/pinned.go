@@ -0,0 +1,18 @@
+package directives
+
+import (
+	"github.com/example/module/pkg/one"
+	"os"
+	//goio:off init order matters
+	_ "github.com/example/module/pkg/zzz"
+
+	_ "github.com/example/module/pkg/aaa" // registers after zzz
+	//goio:on
+	"fmt"
+	"github.com/example/other/pkg/two"
+)
+
+func main() {
+	fmt.Println(one.Name, two.Name)
+	os.Exit(0)
+}
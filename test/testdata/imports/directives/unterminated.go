@@ -0,0 +1,15 @@
+package directives
+
+import (
+	"github.com/example/module/pkg/one"
+	"os"
+
+	//goio:off
+	_ "github.com/example/module/pkg/second"
+	// embed after second
+	_ "embed"
+)
+
+func main() {
+	os.Exit(len(one.Name))
+}